@@ -3,21 +3,56 @@ package app
 import (
 	"context"
 	"fmt"
+	"onx-screen-record/internal/common/enum"
+	"onx-screen-record/internal/pkg/config"
+	"onx-screen-record/internal/pkg/cronjob"
+	"onx-screen-record/internal/pkg/db"
+	"onx-screen-record/internal/pkg/helper"
 	"onx-screen-record/internal/pkg/logger"
 	pathHelper "onx-screen-record/internal/pkg/path-file"
+	"onx-screen-record/internal/pkg/taskqueue"
+	"onx-screen-record/internal/repository"
+	"onx-screen-record/internal/service"
+	"sync"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type App struct {
+	// Jobs, Tasks and Uploads are bound separately so the frontend can
+	// manage scheduled jobs, background tasks and uploads
+	Jobs    *SchedulerService
+	Tasks   *TaskService
+	Uploads *UploadService
+
 	appName string
 	ctx     context.Context
 	path    *pathHelper.PathHelper
+
+	db              *db.Database
+	settings        *repository.SettingsRepository
+	managedSettings *repository.ManagedSettingsRepository
+	jobRuns         *repository.JobRunRepository
+	jobLeases       *repository.JobLeaseRepository
+	jobSchedules    *repository.JobScheduleRepository
+	tasks           *repository.TaskRepository
+	uploads         *repository.UploadRepository
+	config          *config.Config
+	api             *helper.APIClient
+	configMu        sync.RWMutex
+	scheduler       *cronjob.Scheduler
+	taskQueue       *taskqueue.Queue
+
+	settingsTransfer       *service.SettingsTransferService
+	managedSettingsService *service.ManagedSettingsService
 }
 
 func NewApp() *App {
 	return &App{
 		appName: "onx-screen-record",
+		Jobs:    NewSchedulerService(),
+		Tasks:   NewTaskService(),
+		Uploads: NewUploadService(),
 	}
 }
 
@@ -26,12 +61,63 @@ func (a *App) Startup(ctx context.Context) {
 
 	a.path = pathHelper.NewPathHelper(a.appName)
 
+	api, err := helper.NewAPIClient("",
+		helper.WithDefaultHeader("Accept", enum.ApplicationJSON.ToString()),
+		helper.WithDefaultHeader("User-Agent", a.appName),
+		helper.WithRetry(helper.HTTPRetryConfig{MaxRetries: 3}),
+	)
+	if err != nil {
+		logger.Error.Printf("Failed to create API client: %v", err)
+		runtime.Quit(ctx)
+		return
+	}
+	a.api = api
+
 	if err := a.initializeDatabase(); err != nil {
 		logger.Error.Printf("Failed to initialize database: %v", err)
 		runtime.Quit(ctx)
 		return
 	}
 
+	if err := a.loadConfig(); err != nil {
+		logger.Error.Printf("Failed to load config: %v", err)
+		runtime.Quit(ctx)
+		return
+	}
+	logger.Info.Printf("Config loaded with profile: %s", a.getConfig().Profile)
+
+	if err := a.initializeScheduler(ctx); err != nil {
+		logger.Error.Printf("Failed to initialize scheduler: %v", err)
+		runtime.Quit(ctx)
+		return
+	}
+
+	a.initializeTaskQueue(ctx)
+}
+
+func (a *App) Shutdown(ctx context.Context) {
+	if a.scheduler != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, schedulerShutdownTimeout)
+		report, err := a.scheduler.Shutdown(shutdownCtx)
+		cancel()
+		if err != nil {
+			logger.Error.Printf("Scheduler did not shut down cleanly, abandoned jobs: %v", report.Abandoned)
+		}
+	}
+
+	if a.taskQueue != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, taskQueueShutdownTimeout)
+		if err := a.taskQueue.Shutdown(shutdownCtx); err != nil {
+			logger.Error.Printf("Task queue did not shut down cleanly: %v", err)
+		}
+		cancel()
+	}
+
+	if a.db != nil {
+		if err := a.db.Close(); err != nil {
+			logger.Error.Printf("Failed to close database: %v", err)
+		}
+	}
 }
 
 // Greet returns a greeting for the given name
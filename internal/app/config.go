@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/config"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// managedSettingsRefreshInterval is how often the tenant policy is fetched
+const managedSettingsRefreshInterval = time.Hour
+
+func (a *App) loadConfig() error {
+	cfg, err := config.NewLoader(a.path, a.settings, a.managedSettings).Load()
+	if err != nil {
+		return err
+	}
+
+	a.configMu.Lock()
+	a.config = cfg
+	a.configMu.Unlock()
+
+	// The API client follows the baseurl setting
+	if a.api != nil {
+		if err := a.api.SetBaseURL(cfg.Get(models.SettingKeyBaseURL)); err != nil {
+			logger.Warning.Printf("Ignoring %s setting: %v", models.SettingKeyBaseURL, err)
+		}
+	}
+
+	// Jobs bound to settings follow config changes live
+	if a.scheduler != nil {
+		if err := a.scheduler.ApplySettings(cfg); err != nil {
+			logger.Warning.Printf("Some job settings were not applied: %v", err)
+		}
+	}
+	return nil
+}
+
+func (a *App) getConfig() *config.Config {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.config
+}
+
+// refreshManagedSettings fetches the tenant policy and reloads the config.
+// The backend and tenant are read without the policy, so a policy clearing
+// them cannot turn off its own refresh.
+func (a *App) refreshManagedSettings(ctx context.Context) error {
+	cfg := a.getConfig()
+	baseURL := cfg.GetUnmanaged(models.SettingKeyBaseURL)
+	tenant := cfg.GetUnmanaged(models.SettingKeyTenant)
+	if baseURL == "" || tenant == "" {
+		logger.Debug.Println("Skipping managed settings refresh, baseurl or tenant not set")
+		return nil
+	}
+
+	count, err := a.managedSettingsService.Refresh(ctx, baseURL, tenant)
+	if err != nil {
+		return err
+	}
+	logger.Info.Printf("Managed settings refreshed, %d keys enforced", count)
+
+	return a.loadConfig()
+}
+
+// GetConfig returns every effective configuration value with its source
+func (a *App) GetConfig() []config.Value {
+	cfg := a.getConfig()
+	if cfg == nil {
+		return []config.Value{}
+	}
+	return cfg.All()
+}
+
+// RefreshManagedSettings fetches the tenant settings policy now
+func (a *App) RefreshManagedSettings() ([]config.Value, error) {
+	if err := a.refreshManagedSettings(a.ctx); err != nil {
+		return nil, err
+	}
+	return a.GetConfig(), nil
+}
@@ -2,6 +2,8 @@ package app
 
 import (
 	"onx-screen-record/internal/pkg/db"
+	"onx-screen-record/internal/repository"
+	"onx-screen-record/internal/service"
 )
 
 func (a *App) initializeDatabase() error {
@@ -9,17 +11,23 @@ func (a *App) initializeDatabase() error {
 	if err != nil {
 		return err
 	}
-	defer database.Close()
 
 	// Run migrations
 	migrator := db.NewMigrator(database.GetDB())
 	if err := migrator.Run(); err != nil {
+		database.Close()
 		return err
-
 	}
 
-	// // Use repository
-	// settingsRepo := repository.NewSettingsRepository(database.GetDB())
-	// settings, _ := settingsRepo.GetAll()
+	a.db = database
+	a.settings = repository.NewSettingsRepository(database.GetDB())
+	a.managedSettings = repository.NewManagedSettingsRepository(database.GetDB())
+	a.jobRuns = repository.NewJobRunRepository(database.GetDB())
+	a.jobLeases = repository.NewJobLeaseRepository(database.GetDB())
+	a.jobSchedules = repository.NewJobScheduleRepository(database.GetDB())
+	a.tasks = repository.NewTaskRepository(database.GetDB())
+	a.uploads = repository.NewUploadRepository(database.GetDB())
+	a.settingsTransfer = service.NewSettingsTransferService(a.appName, a.settings)
+	a.managedSettingsService = service.NewManagedSettingsService(a.managedSettings, a.api)
 	return nil
 }
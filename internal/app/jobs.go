@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/cronjob"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+const (
+	// schedulerShutdownTimeout bounds how long shutdown waits for running jobs
+	schedulerShutdownTimeout = 10 * time.Second
+)
+
+func (a *App) initializeScheduler(ctx context.Context) error {
+	a.scheduler = cronjob.NewScheduler(ctx)
+	a.scheduler.SetRecorder(a.jobRuns)
+	a.scheduler.SetLocker(a.jobLeases)
+	a.scheduler.SetScheduleStore(a.jobSchedules)
+
+	if _, err := a.scheduler.AddJob("managed-settings-refresh", managedSettingsRefreshInterval, a.refreshManagedSettings,
+		cronjob.WithRetry(cronjob.DefaultRetryPolicy),
+		cronjob.WithTimeout(time.Minute),
+		cronjob.WithJitter(time.Minute),
+		cronjob.WithSettings(models.SettingKeyManagedSettingsRefreshSchedule, models.SettingKeyManagedSettingsRefreshEnabled),
+	); err != nil {
+		return err
+	}
+	if err := a.registerMaintenanceJobs(); err != nil {
+		return err
+	}
+
+	if err := a.scheduler.ApplySettings(a.getConfig()); err != nil {
+		logger.Warning.Printf("Some job settings were not applied: %v", err)
+	}
+	a.settingsTransfer.SetValidator(a.validateSetting)
+
+	a.Jobs.attach(ctx, a.scheduler)
+	a.scheduler.StartAll()
+	return nil
+}
+
+// validateSetting rejects values of job settings the scheduler cannot use
+func (a *App) validateSetting(key, value string) error {
+	if a.scheduler == nil {
+		return nil
+	}
+	return a.scheduler.ValidateSetting(key, value)
+}
+
+// GetJobHistory returns the latest runs of a job, or of every job when
+// jobName is empty
+func (a *App) GetJobHistory(jobName string, limit int) ([]models.JobRun, error) {
+	if a.jobRuns == nil {
+		return nil, errors.New("job history is not available")
+	}
+	return a.jobRuns.GetHistory(jobName, limit)
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/cronjob"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+const (
+	// jobHistoryRetention is how long job runs are kept
+	jobHistoryRetention = 30 * 24 * time.Hour
+
+	// taskRetention is how long succeeded and dead tasks are kept
+	taskRetention = 14 * 24 * time.Hour
+
+	// uploadRetention is how long completed uploads are kept
+	uploadRetention = 14 * 24 * time.Hour
+
+	// tempFileRetention is how long files stay in the temporary data directory
+	tempFileRetention = 24 * time.Hour
+
+	// vacuumFreePageRatio is the share of free pages that triggers a vacuum
+	vacuumFreePageRatio = 0.2
+)
+
+// registerMaintenanceJobs adds the built-in database and disk maintenance
+// jobs to the scheduler
+func (a *App) registerMaintenanceJobs() error {
+	if _, err := a.scheduler.AddCronJob("job-history-prune", "@daily", a.pruneHistory,
+		cronjob.WithMisfirePolicy(cronjob.MisfireRunOnce),
+		cronjob.WithSettings(models.SettingKeyJobHistoryPruneSchedule, models.SettingKeyJobHistoryPruneEnabled),
+	); err != nil {
+		return err
+	}
+	if _, err := a.scheduler.AddCronJob("db-optimize", "0 3 * * *", a.optimizeDatabase,
+		cronjob.WithMisfirePolicy(cronjob.MisfireRunOnce),
+		cronjob.WithTimeout(10*time.Minute),
+		cronjob.WithSettings(models.SettingKeyDBOptimizeSchedule, models.SettingKeyDBOptimizeEnabled),
+	); err != nil {
+		return err
+	}
+	if _, err := a.scheduler.AddCronJob("db-vacuum", "0 4 * * 0", a.vacuumDatabase,
+		cronjob.WithMisfirePolicy(cronjob.MisfireRunOnce),
+		cronjob.WithTimeout(30*time.Minute),
+		cronjob.WithSettings(models.SettingKeyDBVacuumSchedule, models.SettingKeyDBVacuumEnabled),
+	); err != nil {
+		return err
+	}
+	if _, err := a.scheduler.AddJob("temp-cleanup", 6*time.Hour, a.cleanTempData,
+		cronjob.WithInitialDelay(time.Minute),
+		cronjob.WithSettings(models.SettingKeyTempCleanupSchedule, models.SettingKeyTempCleanupEnabled),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// pruneHistory deletes job runs, finished tasks, completed uploads and
+// expired job leases older than their retention period
+func (a *App) pruneHistory(ctx context.Context) error {
+	now := time.Now()
+	var errs []error
+
+	runs, err := a.jobRuns.PruneBefore(now.Add(-jobHistoryRetention))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	tasks, err := a.tasks.PruneFinished(now.Add(-taskRetention))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	uploads, err := a.uploads.PruneCompleted(now.Add(-uploadRetention))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	leases, err := a.jobLeases.PruneExpired(now.Add(-jobHistoryRetention))
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	reportResult(ctx, "Pruned %d job runs, %d finished tasks, %d completed uploads and %d expired job leases", runs, tasks, uploads, leases)
+	return errors.Join(errs...)
+}
+
+// optimizeDatabase refreshes the query planner statistics
+func (a *App) optimizeDatabase(ctx context.Context) error {
+	start := time.Now()
+	if err := a.db.Optimize(ctx); err != nil {
+		return err
+	}
+	reportResult(ctx, "Database optimized in %v", time.Since(start))
+	return nil
+}
+
+// vacuumDatabase returns free pages to the file system when there are
+// enough of them
+func (a *App) vacuumDatabase(ctx context.Context) error {
+	report, err := a.db.Vacuum(ctx, vacuumFreePageRatio)
+	if err != nil {
+		return err
+	}
+
+	if report.ReclaimedPages == 0 {
+		reportResult(ctx, "Database vacuum skipped, %d of %d pages free", report.FreePages, report.PageCount)
+		return nil
+	}
+	reportResult(ctx, "Database vacuum reclaimed %d bytes (%d pages, full vacuum: %t)",
+		report.ReclaimedBytes(), report.ReclaimedPages, report.FullVacuum)
+	return nil
+}
+
+// cleanTempData removes stale files from the temporary data directory
+func (a *App) cleanTempData(ctx context.Context) error {
+	files, bytes, err := a.path.CleanTempDataDir(time.Now().Add(-tempFileRetention))
+	if err != nil {
+		return err
+	}
+	reportResult(ctx, "Temp cleanup removed %d files (%d bytes)", files, bytes)
+	return nil
+}
+
+// reportResult logs the outcome of a maintenance job and records it with
+// the job run
+func reportResult(ctx context.Context, format string, args ...interface{}) {
+	result := fmt.Sprintf(format, args...)
+	cronjob.SetResult(ctx, result)
+	logger.Info.Println(result)
+}
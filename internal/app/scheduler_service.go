@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"onx-screen-record/internal/pkg/cronjob"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// JobEventPrefix prefixes the runtime events emitted for job lifecycle
+// changes, e.g. "cronjob:started", "cronjob:succeeded", "cronjob:failed"
+const JobEventPrefix = "cronjob:"
+
+// SchedulerService exposes the scheduler to the frontend
+type SchedulerService struct {
+	ctx       context.Context
+	scheduler *cronjob.Scheduler
+}
+
+// NewSchedulerService creates a new SchedulerService instance
+func NewSchedulerService() *SchedulerService {
+	return &SchedulerService{}
+}
+
+// attach connects the service to a started scheduler and forwards job
+// lifecycle events to the frontend
+func (s *SchedulerService) attach(ctx context.Context, scheduler *cronjob.Scheduler) {
+	s.ctx = ctx
+	s.scheduler = scheduler
+
+	scheduler.SetEventHandler(func(event cronjob.Event) {
+		runtime.EventsEmit(ctx, JobEventPrefix+string(event.Type), event)
+	})
+}
+
+func (s *SchedulerService) getScheduler() (*cronjob.Scheduler, error) {
+	if s.scheduler == nil {
+		return nil, errors.New("scheduler is not available")
+	}
+	return s.scheduler, nil
+}
+
+// ListJobs returns the status of every scheduled job
+func (s *SchedulerService) ListJobs() ([]cronjob.JobStatus, error) {
+	scheduler, err := s.getScheduler()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.ListJobs(), nil
+}
+
+// GetJob returns the status of a job
+func (s *SchedulerService) GetJob(name string) (*cronjob.JobStatus, error) {
+	scheduler, err := s.getScheduler()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.GetJob(name)
+}
+
+// StartJob resumes a stopped job and returns its status
+func (s *SchedulerService) StartJob(name string) (*cronjob.JobStatus, error) {
+	scheduler, err := s.getScheduler()
+	if err != nil {
+		return nil, err
+	}
+	if err := scheduler.Start(name); err != nil {
+		return nil, err
+	}
+	return scheduler.GetJob(name)
+}
+
+// StopJob stops scheduling a job and returns its status; a run in progress
+// is allowed to finish
+func (s *SchedulerService) StopJob(name string) (*cronjob.JobStatus, error) {
+	scheduler, err := s.getScheduler()
+	if err != nil {
+		return nil, err
+	}
+	if err := scheduler.Stop(name); err != nil {
+		return nil, err
+	}
+	return scheduler.GetJob(name)
+}
+
+// TriggerJob runs a job immediately, outside of its schedule
+func (s *SchedulerService) TriggerJob(name string) error {
+	scheduler, err := s.getScheduler()
+	if err != nil {
+		return err
+	}
+	return scheduler.TriggerNow(name)
+}
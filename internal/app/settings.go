@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/service"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var settingsFileFilters = []runtime.FileFilter{
+	{DisplayName: "Settings (*.json)", Pattern: "*.json"},
+}
+
+// UpdateSettingRequest writes a setting if it still has the version the
+// caller read; Version 0 creates a new key
+type UpdateSettingRequest struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Version uint   `json:"version"`
+}
+
+// RevertSettingRequest selects the version a setting is reverted to, either
+// by history entry or by point in time
+type RevertSettingRequest struct {
+	Key       string     `json:"key"`
+	HistoryID uint       `json:"historyId,omitempty"`
+	At        *time.Time `json:"at,omitempty"`
+}
+
+// GetSettings returns every stored setting with its current version
+func (a *App) GetSettings() ([]models.AppSettings, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings are not available")
+	}
+	return a.settings.GetAll()
+}
+
+// UpdateSetting writes a setting with a compare-and-swap on its version; a
+// conflict error means the setting changed and must be re-read
+func (a *App) UpdateSetting(req UpdateSettingRequest) (*models.AppSettings, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings are not available")
+	}
+
+	valueType := enum.SettingTypeEnum(req.Type)
+	if valueType == "" {
+		valueType = enum.SETTING_STRING
+	}
+	if err := service.ValidateSettingValue(valueType, req.Value); err != nil {
+		return nil, err
+	}
+	if err := a.validateSetting(req.Key, req.Value); err != nil {
+		return nil, err
+	}
+
+	setting, err := a.settings.SetIfVersion(req.Key, req.Value, valueType.ToString(), req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return setting, a.loadConfig()
+}
+
+// GetSettingHistory returns the recorded versions of a setting, newest first
+func (a *App) GetSettingHistory(key string) ([]models.SettingHistory, error) {
+	if a.settings == nil {
+		return nil, errors.New("settings are not available")
+	}
+	return a.settings.GetHistory(key, models.MaxSettingHistoryPerKey)
+}
+
+// RevertSetting restores a setting to a previous version
+func (a *App) RevertSetting(req RevertSettingRequest) error {
+	if a.settings == nil {
+		return errors.New("settings are not available")
+	}
+
+	var err error
+	switch {
+	case req.HistoryID != 0:
+		err = a.settings.RevertTo(req.Key, req.HistoryID)
+	case req.At != nil:
+		err = a.settings.RevertToTime(req.Key, *req.At)
+	default:
+		return errors.New("historyId or at is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.loadConfig()
+}
+
+// ExportSettings writes the settings to a JSON file and returns its path; an
+// empty path asks the user where to save it
+func (a *App) ExportSettings(path string, opts service.SettingsExportOptions) (string, error) {
+	if a.settingsTransfer == nil {
+		return "", errors.New("settings are not available")
+	}
+
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("%s-settings.json", a.appName),
+			Filters:         settingsFileFilters,
+		})
+		if err != nil || selected == "" {
+			return "", err
+		}
+		path = selected
+	}
+
+	return path, a.settingsTransfer.ExportToFile(path, opts)
+}
+
+// PreviewSettingsImport returns the changes importing a settings file would
+// make; an empty path asks the user to pick a file
+func (a *App) PreviewSettingsImport(path string, passphrase string) (*service.SettingsImportPreview, error) {
+	path, doc, err := a.readSettingsFile(path)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	preview, err := a.settingsTransfer.Preview(doc, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Path = path
+	return preview, nil
+}
+
+// ImportSettings validates and applies a settings file
+func (a *App) ImportSettings(path string, passphrase string) (*service.SettingsImportPreview, error) {
+	path, doc, err := a.readSettingsFile(path)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	preview, err := a.settingsTransfer.Import(doc, passphrase)
+	if preview != nil {
+		preview.Path = path
+	}
+	if err != nil {
+		return preview, err
+	}
+
+	return preview, a.loadConfig()
+}
+
+func (a *App) readSettingsFile(path string) (string, *service.SettingsDocument, error) {
+	if a.settingsTransfer == nil {
+		return "", nil, errors.New("settings are not available")
+	}
+
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Filters: settingsFileFilters,
+		})
+		if err != nil || selected == "" {
+			return "", nil, err
+		}
+		path = selected
+	}
+
+	doc, err := a.settingsTransfer.ReadFile(path)
+	return path, doc, err
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/taskqueue"
+	"onx-screen-record/internal/repository"
+)
+
+// taskQueueShutdownTimeout bounds how long shutdown waits for running tasks
+const taskQueueShutdownTimeout = 10 * time.Second
+
+func (a *App) initializeTaskQueue(ctx context.Context) {
+	workers := a.getConfig().GetInt(models.SettingKeyTaskWorkers, taskqueue.DefaultWorkers)
+
+	a.taskQueue = taskqueue.NewQueue(ctx, a.tasks, taskqueue.WithWorkers(workers))
+	a.Tasks.attach(a.tasks, a.taskQueue)
+	a.initializeUploads(ctx)
+	a.taskQueue.Start()
+}
+
+// TaskService exposes the background task queue to the frontend
+type TaskService struct {
+	tasks *repository.TaskRepository
+	queue *taskqueue.Queue
+}
+
+// NewTaskService creates a new TaskService instance
+func NewTaskService() *TaskService {
+	return &TaskService{}
+}
+
+func (s *TaskService) attach(tasks *repository.TaskRepository, queue *taskqueue.Queue) {
+	s.tasks = tasks
+	s.queue = queue
+}
+
+func (s *TaskService) getTasks() (*repository.TaskRepository, error) {
+	if s.tasks == nil {
+		return nil, errors.New("task queue is not available")
+	}
+	return s.tasks, nil
+}
+
+// ListTasks returns the latest tasks, optionally filtered by status and type
+func (s *TaskService) ListTasks(status string, taskType string, limit int) ([]models.Task, error) {
+	tasks, err := s.getTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	taskStatus := enum.TaskStatusEnum(status)
+	if status != "" && !taskStatus.IsValid() {
+		return nil, errors.New("invalid task status: " + status)
+	}
+	return tasks.List(taskStatus, taskType, limit)
+}
+
+// GetTask returns a task by id
+func (s *TaskService) GetTask(id uint) (*models.Task, error) {
+	tasks, err := s.getTasks()
+	if err != nil {
+		return nil, err
+	}
+	return tasks.Get(id)
+}
+
+// GetTaskCounts returns the number of tasks in every status
+func (s *TaskService) GetTaskCounts() (map[enum.TaskStatusEnum]int64, error) {
+	tasks, err := s.getTasks()
+	if err != nil {
+		return nil, err
+	}
+	return tasks.CountByStatus()
+}
+
+// RetryTask makes a dead or waiting task run again as soon as possible
+func (s *TaskService) RetryTask(id uint) (*models.Task, error) {
+	tasks, err := s.getTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := tasks.Retry(id)
+	if err != nil {
+		return nil, err
+	}
+	s.queue.Notify()
+	return task, nil
+}
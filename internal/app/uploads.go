@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/logger"
+	"onx-screen-record/internal/pkg/taskqueue"
+	"onx-screen-record/internal/pkg/upload"
+	"onx-screen-record/internal/repository"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// TaskTypeUpload is the task type uploading a file in the background
+	TaskTypeUpload = "upload"
+
+	// UploadProgressEvent is emitted with an UploadProgress while a file is
+	// uploaded
+	UploadProgressEvent = "upload:progress"
+
+	// defaultUploadEndpoint creates upload sessions when the upload_endpoint
+	// setting is empty
+	defaultUploadEndpoint = "/uploads"
+)
+
+// uploadTask is the payload of an upload task
+type uploadTask struct {
+	Path        string            `json:"path"`
+	Endpoint    string            `json:"endpoint"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// UploadProgress reports the bytes of a file confirmed by the server
+type UploadProgress struct {
+	TaskID uint   `json:"task_id"`
+	Path   string `json:"path"`
+	Sent   int64  `json:"sent"`
+	Total  int64  `json:"total"`
+}
+
+func (a *App) initializeUploads(ctx context.Context) {
+	uploader := upload.NewUploader(a.api, a.uploads)
+
+	a.taskQueue.Register(TaskTypeUpload, func(taskCtx context.Context, task *models.Task) error {
+		var payload uploadTask
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return taskqueue.Permanent(fmt.Errorf("invalid upload task payload: %w", err))
+		}
+
+		_, err := uploader.Upload(taskCtx, upload.Request{
+			Path:     payload.Path,
+			Endpoint: payload.Endpoint,
+			Metadata: payload.Metadata,
+			OnProgress: func(sent, total int64) {
+				runtime.EventsEmit(ctx, UploadProgressEvent, UploadProgress{
+					TaskID: task.ID,
+					Path:   payload.Path,
+					Sent:   sent,
+					Total:  total,
+				})
+			},
+		})
+		// Retrying does not bring back a removed or changed file
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, upload.ErrFileChanged) {
+			return taskqueue.Permanent(err)
+		}
+		return err
+	})
+
+	a.Uploads.attach(a, a.uploads)
+}
+
+// UploadService exposes resumable uploads to the frontend
+type UploadService struct {
+	app     *App
+	uploads *repository.UploadRepository
+	// mu serializes UploadFile so a file is never enqueued twice
+	mu sync.Mutex
+}
+
+// NewUploadService creates a new UploadService instance
+func NewUploadService() *UploadService {
+	return &UploadService{}
+}
+
+func (s *UploadService) attach(app *App, uploads *repository.UploadRepository) {
+	s.app = app
+	s.uploads = uploads
+}
+
+func (s *UploadService) getUploads() (*repository.UploadRepository, error) {
+	if s.uploads == nil {
+		return nil, errors.New("uploads are not available")
+	}
+	return s.uploads, nil
+}
+
+// UploadFile uploads a file in the background; an interrupted upload of the
+// same file continues where it stopped. While an upload of the unchanged
+// file is pending or running, its task is returned instead of a new one.
+func (s *UploadService) UploadFile(path string) (*models.Task, error) {
+	if _, err := s.getUploads(); err != nil {
+		return nil, err
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, errors.New("cannot upload a directory: " + path)
+	}
+
+	endpoint := s.app.getConfig().Get(models.SettingKeyUploadEndpoint)
+	if endpoint == "" {
+		endpoint = defaultUploadEndpoint
+	}
+	fingerprint := upload.Fingerprint(path, info)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findUploadTask(endpoint, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		logger.Info.Printf("Upload of %s is already queued as task %d", path, existing.ID)
+		return existing, nil
+	}
+
+	return s.app.taskQueue.Enqueue(TaskTypeUpload, uploadTask{
+		Path:        path,
+		Endpoint:    endpoint,
+		Fingerprint: fingerprint,
+		Metadata:    map[string]string{"filename": filepath.Base(path)},
+	})
+}
+
+// findUploadTask returns the pending or running upload task of a file, or
+// nil when there is none
+func (s *UploadService) findUploadTask(endpoint, fingerprint string) (*models.Task, error) {
+	for _, status := range []enum.TaskStatusEnum{enum.TASK_RUNNING, enum.TASK_PENDING} {
+		tasks, err := s.app.tasks.List(status, TaskTypeUpload, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range tasks {
+			var payload uploadTask
+			if err := json.Unmarshal([]byte(tasks[i].Payload), &payload); err != nil {
+				continue
+			}
+			if payload.Endpoint == endpoint && payload.Fingerprint == fingerprint {
+				return &tasks[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ListUploads returns the latest uploads, optionally filtered by status
+func (s *UploadService) ListUploads(status string, limit int) ([]models.Upload, error) {
+	uploads, err := s.getUploads()
+	if err != nil {
+		return nil, err
+	}
+
+	uploadStatus := enum.UploadStatusEnum(status)
+	if status != "" && !uploadStatus.IsValid() {
+		return nil, errors.New("invalid upload status: " + status)
+	}
+	return uploads.List(uploadStatus, limit)
+}
+
+// GetUpload returns an upload by id
+func (s *UploadService) GetUpload(id uint) (*models.Upload, error) {
+	uploads, err := s.getUploads()
+	if err != nil {
+		return nil, err
+	}
+	return uploads.Get(id)
+}
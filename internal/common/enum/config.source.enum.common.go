@@ -0,0 +1,36 @@
+package enum
+
+type ConfigSourceEnum string
+
+const (
+	SourceDefault     ConfigSourceEnum = "default"
+	SourceFile        ConfigSourceEnum = "file"
+	SourceDatabase    ConfigSourceEnum = "database"
+	SourceEnvironment ConfigSourceEnum = "environment"
+	SourceManaged     ConfigSourceEnum = "managed"
+)
+
+func (e ConfigSourceEnum) ToString() string {
+	switch e {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceDatabase:
+		return "database"
+	case SourceEnvironment:
+		return "environment"
+	case SourceManaged:
+		return "managed"
+	default:
+		return ""
+	}
+}
+
+func (e ConfigSourceEnum) IsValid() bool {
+	switch e {
+	case SourceDefault, SourceFile, SourceDatabase, SourceEnvironment, SourceManaged:
+		return true
+	}
+	return false
+}
@@ -3,10 +3,13 @@ package enum
 type HTTPMethodEnum string
 
 const (
-	GET    HTTPMethodEnum = "GET"
-	POST   HTTPMethodEnum = "POST"
-	PUT    HTTPMethodEnum = "PUT"
-	DELETE HTTPMethodEnum = "DELETE"
+	GET     HTTPMethodEnum = "GET"
+	POST    HTTPMethodEnum = "POST"
+	PUT     HTTPMethodEnum = "PUT"
+	PATCH   HTTPMethodEnum = "PATCH"
+	DELETE  HTTPMethodEnum = "DELETE"
+	HEAD    HTTPMethodEnum = "HEAD"
+	OPTIONS HTTPMethodEnum = "OPTIONS"
 )
 
 func (e HTTPMethodEnum) ToString() string {
@@ -17,9 +20,42 @@ func (e HTTPMethodEnum) ToString() string {
 		return "POST"
 	case PUT:
 		return "PUT"
+	case PATCH:
+		return "PATCH"
 	case DELETE:
 		return "DELETE"
+	case HEAD:
+		return "HEAD"
+	case OPTIONS:
+		return "OPTIONS"
 	default:
 		return ""
 	}
 }
+
+func (e HTTPMethodEnum) IsValid() bool {
+	switch e {
+	case GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS:
+		return true
+	}
+	return false
+}
+
+// AllowsBody reports whether requests with this method may carry a body
+func (e HTTPMethodEnum) AllowsBody() bool {
+	switch e {
+	case GET, HEAD:
+		return false
+	}
+	return true
+}
+
+// IsIdempotent reports whether repeating a request with this method has the
+// same effect as sending it once, so it is safe to retry
+func (e HTTPMethodEnum) IsIdempotent() bool {
+	switch e {
+	case GET, HEAD, OPTIONS, PUT, DELETE:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package enum
+
+type JobRunStatusEnum string
+
+const (
+	JOB_SUCCESS JobRunStatusEnum = "success"
+	JOB_FAILED  JobRunStatusEnum = "failed"
+)
+
+func (e JobRunStatusEnum) ToString() string {
+	switch e {
+	case JOB_SUCCESS:
+		return "success"
+	case JOB_FAILED:
+		return "failed"
+	default:
+		return ""
+	}
+}
+
+func (e JobRunStatusEnum) IsValid() bool {
+	switch e {
+	case JOB_SUCCESS, JOB_FAILED:
+		return true
+	}
+	return false
+}
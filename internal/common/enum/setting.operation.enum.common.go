@@ -0,0 +1,36 @@
+package enum
+
+type SettingOperationEnum string
+
+const (
+	SETTING_SNAPSHOT SettingOperationEnum = "snapshot"
+	SETTING_CREATE   SettingOperationEnum = "create"
+	SETTING_UPDATE   SettingOperationEnum = "update"
+	SETTING_DELETE   SettingOperationEnum = "delete"
+	SETTING_REVERT   SettingOperationEnum = "revert"
+)
+
+func (e SettingOperationEnum) ToString() string {
+	switch e {
+	case SETTING_SNAPSHOT:
+		return "snapshot"
+	case SETTING_CREATE:
+		return "create"
+	case SETTING_UPDATE:
+		return "update"
+	case SETTING_DELETE:
+		return "delete"
+	case SETTING_REVERT:
+		return "revert"
+	default:
+		return ""
+	}
+}
+
+func (e SettingOperationEnum) IsValid() bool {
+	switch e {
+	case SETTING_SNAPSHOT, SETTING_CREATE, SETTING_UPDATE, SETTING_DELETE, SETTING_REVERT:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package enum
+
+type SettingTypeEnum string
+
+const (
+	SETTING_STRING SettingTypeEnum = "string"
+	SETTING_INT    SettingTypeEnum = "int"
+	SETTING_BOOL   SettingTypeEnum = "bool"
+	SETTING_JSON   SettingTypeEnum = "json"
+)
+
+func (e SettingTypeEnum) ToString() string {
+	switch e {
+	case SETTING_STRING:
+		return "string"
+	case SETTING_INT:
+		return "int"
+	case SETTING_BOOL:
+		return "bool"
+	case SETTING_JSON:
+		return "json"
+	default:
+		return ""
+	}
+}
+
+func (e SettingTypeEnum) IsValid() bool {
+	switch e {
+	case SETTING_STRING, SETTING_INT, SETTING_BOOL, SETTING_JSON:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package enum
+
+type TaskStatusEnum string
+
+const (
+	TASK_PENDING   TaskStatusEnum = "pending"
+	TASK_RUNNING   TaskStatusEnum = "running"
+	TASK_SUCCEEDED TaskStatusEnum = "succeeded"
+	TASK_DEAD      TaskStatusEnum = "dead"
+)
+
+func (e TaskStatusEnum) ToString() string {
+	switch e {
+	case TASK_PENDING:
+		return "pending"
+	case TASK_RUNNING:
+		return "running"
+	case TASK_SUCCEEDED:
+		return "succeeded"
+	case TASK_DEAD:
+		return "dead"
+	default:
+		return ""
+	}
+}
+
+func (e TaskStatusEnum) IsValid() bool {
+	switch e {
+	case TASK_PENDING, TASK_RUNNING, TASK_SUCCEEDED, TASK_DEAD:
+		return true
+	}
+	return false
+}
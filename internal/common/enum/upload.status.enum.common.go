@@ -0,0 +1,30 @@
+package enum
+
+type UploadStatusEnum string
+
+const (
+	UPLOAD_PENDING   UploadStatusEnum = "pending"
+	UPLOAD_COMPLETED UploadStatusEnum = "completed"
+	UPLOAD_FAILED    UploadStatusEnum = "failed"
+)
+
+func (e UploadStatusEnum) ToString() string {
+	switch e {
+	case UPLOAD_PENDING:
+		return "pending"
+	case UPLOAD_COMPLETED:
+		return "completed"
+	case UPLOAD_FAILED:
+		return "failed"
+	default:
+		return ""
+	}
+}
+
+func (e UploadStatusEnum) IsValid() bool {
+	switch e {
+	case UPLOAD_PENDING, UPLOAD_COMPLETED, UPLOAD_FAILED:
+		return true
+	}
+	return false
+}
@@ -10,6 +10,7 @@ type AppSettings struct {
 	Key       string    `gorm:"uniqueIndex;size:255;not null" json:"key"`
 	Value     string    `gorm:"type:text" json:"value"`
 	Type      string    `gorm:"size:50;default:'string'" json:"type"` // string, int, bool, json
+	Version   uint      `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -29,4 +30,30 @@ const (
 	SettingKeyHotkey           = "hotkey"
 	SettingKeyLanguage         = "language"
 	SettingKeyTheme            = "theme"
+	SettingKeyTenant           = "tenant"
+	SettingKeyBaseURL          = "baseurl"
+	SettingKeyMQTT             = "mqtt"
+	SettingKeyTaskWorkers      = "task_workers"
+	SettingKeyUploadEndpoint   = "upload_endpoint"
 )
+
+// Job setting keys; schedules accept a duration such as "30m" or a cron
+// expression, enabled flags accept a bool
+const (
+	SettingKeyManagedSettingsRefreshSchedule = "managed_settings_refresh_schedule"
+	SettingKeyManagedSettingsRefreshEnabled  = "managed_settings_refresh_enabled"
+	SettingKeyJobHistoryPruneSchedule        = "job_history_prune_schedule"
+	SettingKeyJobHistoryPruneEnabled         = "job_history_prune_enabled"
+	SettingKeyDBOptimizeSchedule             = "db_optimize_schedule"
+	SettingKeyDBOptimizeEnabled              = "db_optimize_enabled"
+	SettingKeyDBVacuumSchedule               = "db_vacuum_schedule"
+	SettingKeyDBVacuumEnabled                = "db_vacuum_enabled"
+	SettingKeyTempCleanupSchedule            = "temp_cleanup_schedule"
+	SettingKeyTempCleanupEnabled             = "temp_cleanup_enabled"
+)
+
+// SecretSettingKeys are never exported in plain text; keys ending in
+// _token, _password or _secret are treated the same way
+var SecretSettingKeys = []string{
+	SettingKeyMQTT,
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// JobLease records which app instance currently executes a cron job
+type JobLease struct {
+	JobName    string    `gorm:"primaryKey;size:255" json:"job_name"`
+	Owner      string    `gorm:"size:255;not null" json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName returns the table name for JobLease
+func (JobLease) TableName() string {
+	return "job_leases"
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+)
+
+// JobRun represents a single execution of a cron job
+type JobRun struct {
+	ID         uint                  `gorm:"primaryKey" json:"id"`
+	JobName    string                `gorm:"index;size:255;not null" json:"job_name"`
+	Status     enum.JobRunStatusEnum `gorm:"size:20;not null" json:"status"`
+	Attempt    int                   `gorm:"not null;default:1" json:"attempt"`
+	Error      string                `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time             `gorm:"index" json:"started_at"`
+	FinishedAt time.Time             `json:"finished_at"`
+	DurationMs int64                 `json:"duration_ms"`
+	Result     string                `gorm:"type:text" json:"result,omitempty"`
+}
+
+// TableName returns the table name for JobRun
+func (JobRun) TableName() string {
+	return "job_runs"
+}
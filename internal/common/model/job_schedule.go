@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// JobSchedule keeps the last activation of a cron job across app restarts
+type JobSchedule struct {
+	JobName   string    `gorm:"primaryKey;size:255" json:"job_name"`
+	LastRunAt time.Time `json:"last_run_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for JobSchedule
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
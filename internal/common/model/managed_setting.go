@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ManagedSetting represents a setting value enforced by the tenant policy
+type ManagedSetting struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Key       string    `gorm:"uniqueIndex;size:255;not null" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"`
+	Type      string    `gorm:"size:50;default:'string'" json:"type"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// TableName returns the table name for ManagedSetting
+func (ManagedSetting) TableName() string {
+	return "managed_settings"
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+)
+
+// SettingHistory represents a past version of an app setting
+type SettingHistory struct {
+	ID        uint                      `gorm:"primaryKey" json:"id"`
+	Key       string                    `gorm:"index;size:255;not null" json:"key"`
+	Value     string                    `gorm:"type:text" json:"value"`
+	Type      string                    `gorm:"size:50" json:"type"`
+	Operation enum.SettingOperationEnum `gorm:"size:20;not null" json:"operation"`
+	CreatedAt time.Time                 `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for SettingHistory
+func (SettingHistory) TableName() string {
+	return "app_settings_history"
+}
+
+// MaxSettingHistoryPerKey bounds how many versions are kept for each key
+const MaxSettingHistoryPerKey = 50
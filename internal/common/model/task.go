@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+)
+
+// Task represents a one-off background task in the persistent queue
+type Task struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	Type        string              `gorm:"index;size:100;not null" json:"type"`
+	Payload     string              `gorm:"type:text" json:"payload"`
+	Priority    int                 `gorm:"not null;default:0" json:"priority"`
+	Status      enum.TaskStatusEnum `gorm:"size:20;not null" json:"status"`
+	Attempts    int                 `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int                 `gorm:"not null;default:5" json:"max_attempts"`
+	LastError   string              `gorm:"type:text" json:"last_error,omitempty"`
+	RunAt       time.Time           `json:"run_at"`
+	LockedBy    string              `gorm:"size:255" json:"locked_by,omitempty"`
+	LockedUntil *time.Time          `json:"locked_until,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+}
+
+// TableName returns the table name for Task
+func (Task) TableName() string {
+	return "tasks"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+)
+
+// Upload keeps the state of a resumable chunked upload so it continues
+// from the last confirmed offset after a failure or an app restart
+type Upload struct {
+	ID          uint                  `gorm:"primaryKey" json:"id"`
+	FilePath    string                `gorm:"type:text;not null" json:"file_path"`
+	Fingerprint string                `gorm:"size:64;not null" json:"fingerprint"`
+	Endpoint    string                `gorm:"type:text;not null" json:"endpoint"`
+	UploadURL   string                `gorm:"type:text" json:"upload_url,omitempty"`
+	Metadata    string                `gorm:"type:text" json:"metadata,omitempty"`
+	Size        int64                 `gorm:"not null" json:"size"`
+	Offset      int64                 `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Status      enum.UploadStatusEnum `gorm:"size:20;not null" json:"status"`
+	LastError   string                `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// TableName returns the table name for Upload
+func (Upload) TableName() string {
+	return "uploads"
+}
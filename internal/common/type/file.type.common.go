@@ -1,5 +1,7 @@
 package types
 
+import "io"
+
 type BufferedFile struct {
 	MediaType    string `json:"mediaType" validate:"required"`
 	OriginalName string `json:"originalName" validate:"required"`
@@ -10,3 +12,16 @@ type BufferedFile struct {
 }
 
 type BufferedFiles map[string][]BufferedFile
+
+// StreamedFile is a multipart file read while the request is sent instead
+// of being held in memory. It is read from Path or, when Path is empty,
+// from Reader, which can only be sent once.
+type StreamedFile struct {
+	OriginalName string
+	MimeType     string
+	Path         string
+	Reader       io.Reader
+	// Size is the length of Reader, 0 when unknown; it is read from the
+	// file for Path
+	Size int64
+}
@@ -0,0 +1,65 @@
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes an exponential backoff with jitter
+type Policy struct {
+	Initial    time.Duration // delay before the first retry
+	Max        time.Duration // upper bound of any delay
+	Multiplier float64       // growth factor between retries
+	Jitter     float64       // fraction of the delay randomised, 0 to 1
+}
+
+// Default is a sensible policy for network calls
+var Default = Policy{
+	Initial:    time.Second,
+	Max:        5 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay returns how long to wait before the given retry, starting at 1
+func (p Policy) Delay(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	initial := p.Initial
+	if initial <= 0 {
+		initial = Default.Initial
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay * (1 - jitter + 2*jitter*rand.Float64())
+	}
+
+	return time.Duration(delay)
+}
+
+// Sleep waits for d or until ctx is done, returning ctx.Err() in that case
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
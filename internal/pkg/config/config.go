@@ -0,0 +1,105 @@
+package config
+
+import (
+	"sort"
+	"strconv"
+
+	"onx-screen-record/internal/common/enum"
+	types "onx-screen-record/internal/common/type"
+)
+
+// Value represents an effective configuration value and where it came from
+type Value struct {
+	Key    string                `json:"key"`
+	Value  string                `json:"value"`
+	Source enum.ConfigSourceEnum `json:"source"`
+	Locked bool                  `json:"locked"`
+}
+
+// Config holds the merged configuration for the active profile
+type Config struct {
+	Profile enum.EnvEnum
+	values  map[string]Value
+	// unmanaged keeps the values of the layers below the managed policy
+	unmanaged map[string]Value
+}
+
+func newConfig(profile enum.EnvEnum) *Config {
+	return &Config{
+		Profile:   profile,
+		values:    make(map[string]Value),
+		unmanaged: make(map[string]Value),
+	}
+}
+
+// set overrides a key; empty values never override a lower layer
+func (c *Config) set(key, value string, source enum.ConfigSourceEnum) {
+	if value == "" {
+		return
+	}
+	c.values[key] = Value{Key: key, Value: value, Source: source}
+}
+
+// lock enforces a managed value, even an empty one
+func (c *Config) lock(key, value string) {
+	if current, ok := c.values[key]; ok && !current.Locked {
+		c.unmanaged[key] = current
+	}
+	c.values[key] = Value{Key: key, Value: value, Source: enum.SourceManaged, Locked: true}
+}
+
+// GetUnmanaged returns the value a key has without the managed policy, e.g.
+// to reach the backend serving the policy when the policy itself clears it
+func (c *Config) GetUnmanaged(key string) string {
+	if c.values[key].Locked {
+		return c.unmanaged[key].Value
+	}
+	return c.values[key].Value
+}
+
+// IsLocked reports whether a key is enforced by the managed policy
+func (c *Config) IsLocked(key string) bool {
+	return c.values[key].Locked
+}
+
+// Lookup returns the effective value for a key and whether it is set
+func (c *Config) Lookup(key string) (Value, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Get returns the effective value for a key, or an empty string
+func (c *Config) Get(key string) string {
+	return c.values[key].Value
+}
+
+// GetBool returns the effective value for a key parsed as a bool
+func (c *Config) GetBool(key string) bool {
+	return types.StringToBool(c.Get(key)).ToBool()
+}
+
+// GetInt returns the effective value for a key parsed as an int, or fallback
+func (c *Config) GetInt(key string, fallback int) int {
+	value, err := strconv.Atoi(c.Get(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Source returns which layer provided the effective value for a key
+func (c *Config) Source(key string) enum.ConfigSourceEnum {
+	return c.values[key].Source
+}
+
+// All returns every effective value sorted by key
+func (c *Config) All() []Value {
+	result := make([]Value, 0, len(c.values))
+	for _, v := range c.values {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
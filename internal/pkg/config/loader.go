@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/helper"
+	"onx-screen-record/internal/pkg/logger"
+	pathHelper "onx-screen-record/internal/pkg/path-file"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Configuration is merged from the following layers, lowest precedence first:
+//
+//  1. built-in defaults
+//  2. config file "settings" section
+//  3. config file "profiles.<profile>" section
+//  4. app_settings table
+//  5. ONX_* environment variables
+//  6. managed_settings table (tenant policy, read-only for the user)
+//
+// Empty values never override a lower layer, so NULL rows seeded by the
+// migrations do not hide values preseeded through the config file. Managed
+// values always win, even when empty.
+//
+// The profile is taken from ONX_ENV, then the config file "profile" field,
+// and falls back to production.
+const (
+	EnvPrefix      = "ONX_"
+	EnvProfile     = EnvPrefix + "ENV"
+	EnvConfigFile  = EnvPrefix + "CONFIG"
+	DefaultProfile = enum.PRODUCTION
+)
+
+// configFileNames are looked up in the app data directory in order
+var configFileNames = []string{"config.json", "config.toml"}
+
+// defaults are the built-in values shared by every profile
+var defaults = map[string]string{
+	models.SettingKeyAutoStart:        "false",
+	models.SettingKeyRecordingQuality: "high",
+	models.SettingKeyMaxStorageGB:     "10",
+	models.SettingKeyNotifications:    "true",
+	models.SettingKeyLanguage:         "en",
+	models.SettingKeyTheme:            "system",
+}
+
+// SettingsSource provides the values stored in the app_settings or
+// managed_settings table
+type SettingsSource interface {
+	GetAsMap() (map[string]string, error)
+}
+
+// fileDocument is the shape of config.json / config.toml
+type fileDocument struct {
+	Profile  string                            `json:"profile" toml:"profile"`
+	Settings map[string]interface{}            `json:"settings" toml:"settings"`
+	Profiles map[string]map[string]interface{} `json:"profiles" toml:"profiles"`
+}
+
+// Loader builds a Config from all configuration layers
+type Loader struct {
+	path     *pathHelper.PathHelper
+	settings SettingsSource
+	managed  SettingsSource
+}
+
+// NewLoader creates a new Loader instance
+func NewLoader(ph *pathHelper.PathHelper, settings SettingsSource, managed SettingsSource) *Loader {
+	return &Loader{
+		path:     ph,
+		settings: settings,
+		managed:  managed,
+	}
+}
+
+// Load reads every layer and returns the merged configuration
+func (l *Loader) Load() (*Config, error) {
+	doc, err := l.readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := resolveProfile(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newConfig(profile)
+
+	for key, value := range defaults {
+		cfg.set(key, value, enum.SourceDefault)
+	}
+
+	if doc != nil {
+		for key, value := range doc.Settings {
+			cfg.set(key, stringify(value), enum.SourceFile)
+		}
+		for key, value := range doc.Profiles[profile.ToString()] {
+			cfg.set(key, stringify(value), enum.SourceFile)
+		}
+	}
+
+	if l.settings != nil {
+		stored, err := l.settings.GetAsMap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settings: %w", err)
+		}
+		for key, value := range stored {
+			cfg.set(key, value, enum.SourceDatabase)
+		}
+	}
+
+	for key, value := range environmentValues() {
+		cfg.set(key, value, enum.SourceEnvironment)
+	}
+
+	if l.managed != nil {
+		managed, err := l.managed.GetAsMap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read managed settings: %w", err)
+		}
+		for key, value := range managed {
+			cfg.lock(key, value)
+		}
+	}
+
+	return cfg, nil
+}
+
+// ConfigFilePath returns the config file that will be read, if any
+func (l *Loader) ConfigFilePath() (string, error) {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path, nil
+	}
+
+	appDataDir, err := l.path.GetAppDataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get app data directory: %w", err)
+	}
+
+	for _, name := range configFileNames {
+		path := filepath.Join(appDataDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", nil
+}
+
+// readConfigFile parses the config file, returning nil when there is none
+func (l *Loader) readConfigFile() (*fileDocument, error) {
+	path, err := l.ConfigFilePath()
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var doc fileDocument
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &doc)
+	case ".toml":
+		err = toml.Unmarshal(content, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	logger.Info.Printf("Loaded config file: %s", path)
+	return &doc, nil
+}
+
+func resolveProfile(doc *fileDocument) (enum.EnvEnum, error) {
+	profile := enum.EnvEnum(strings.ToLower(os.Getenv(EnvProfile)))
+	if profile == "" && doc != nil {
+		profile = enum.EnvEnum(strings.ToLower(doc.Profile))
+	}
+	if profile == "" {
+		return DefaultProfile, nil
+	}
+	if !profile.IsValid() {
+		return "", errors.New("invalid config profile: " + string(profile))
+	}
+	return profile, nil
+}
+
+// environmentValues maps ONX_SOME_KEY=value to some_key=value
+func environmentValues() map[string]string {
+	result := make(map[string]string)
+	for _, entry := range os.Environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		if name == EnvProfile || name == EnvConfigFile {
+			continue
+		}
+		result[strings.ToLower(strings.TrimPrefix(name, EnvPrefix))] = value
+	}
+	return result
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s, err := helper.JSONToString(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return s
+	}
+}
@@ -0,0 +1,236 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the scheduler. The real clock is used by
+// default; a FakeClock makes schedules testable without sleeping.
+type Clock interface {
+	Now() time.Time
+	// NewTimer creates a timer delivering the current time on C after d
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine after d
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a single pending event of a Clock
+type Timer interface {
+	// C returns the channel the time is delivered on, nil for AfterFunc timers
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, reporting whether it was pending
+	Stop() bool
+}
+
+// RealClock is the Clock backed by the time package
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return &realTimer{timer: time.AfterFunc(d, f)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// withTimeout is context.WithTimeout measured on clock
+func withTimeout(clock Clock, parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
+	timer := clock.AfterFunc(timeout, func() {
+		cancel(context.DeadlineExceeded)
+	})
+
+	return &timeoutContext{Context: ctx, deadline: clock.Now().Add(timeout)}, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// timeoutContext reports context.DeadlineExceeded once its clock timer fired
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && errors.Is(context.Cause(c.Context), context.DeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// FakeClock is a Clock that only moves when told to. Timers fire, in order
+// of their deadline, when Advance or Set moves the time past them.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFakeClock creates a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	clock := &FakeClock{now: now}
+	clock.cond = sync.NewCond(&clock.mu)
+	return clock
+}
+
+// Now returns the current fake time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer firing once the fake time reaches now + d
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.addTimer(d, make(chan time.Time, 1), nil)
+}
+
+// AfterFunc calls f once the fake time reaches now + d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.addTimer(d, nil, f)
+}
+
+// Advance moves the time forward by d and fires the timers due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.fire(c.now.Add(d))
+}
+
+// Set moves the time to t and fires the timers due; moving the time
+// backwards simulates a wall clock adjustment
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.fire(t)
+}
+
+// Waiters returns the number of pending timers
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil waits until at least n timers are pending, which is how a test
+// knows the scheduler has armed its next activation
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) addTimer(d time.Duration, ch chan time.Time, f func()) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{clock: c, when: c.now.Add(d), c: ch, fn: f}
+	if d <= 0 {
+		timer.deliver(c.now)
+		return timer
+	}
+
+	c.waiters = append(c.waiters, timer)
+	c.cond.Broadcast()
+	return timer
+}
+
+// fire sets the time and delivers every due timer; it expects c.mu to be
+// held and releases it
+func (c *FakeClock) fire(now time.Time) {
+	c.now = now
+
+	var due, pending []*fakeTimer
+	for _, timer := range c.waiters {
+		if timer.when.After(now) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	c.waiters = pending
+	c.cond.Broadcast()
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].when.Before(due[j].when)
+	})
+	for _, timer := range due {
+		timer.deliver(now)
+	}
+}
+
+func (c *FakeClock) removeTimer(timer *fakeTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, waiter := range c.waiters {
+		if waiter == timer {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	c     chan time.Time
+	fn    func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.removeTimer(t)
+}
+
+func (t *fakeTimer) deliver(now time.Time) {
+	if t.fn != nil {
+		go t.fn()
+		return
+	}
+
+	select {
+	case t.c <- now:
+	default:
+	}
+}
@@ -2,7 +2,12 @@ package cronjob
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/helper"
 	"onx-screen-record/internal/pkg/logger"
 	"sync"
 	"time"
@@ -11,84 +16,370 @@ import (
 // JobFunc defines the function signature for cron jobs
 type JobFunc func(ctx context.Context) error
 
+var (
+	// ErrJobNotFound is returned when no job has the given name
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobExists is returned when adding a job whose name is taken
+	ErrJobExists = errors.New("job already exists")
+
+	// ErrSchedulerClosed is returned once the scheduler has been shut down
+	ErrSchedulerClosed = errors.New("scheduler is shut down")
+)
+
+// RunRecorder persists the outcome of every job run
+type RunRecorder interface {
+	RecordRun(run *models.JobRun) error
+}
+
 // Job represents a scheduled job
 type Job struct {
 	Name     string
 	Interval time.Duration
+	Schedule Schedule
 	Fn       JobFunc
 	stopChan chan struct{}
 	running  bool
 	mu       sync.RWMutex
+	runs     sync.WaitGroup
+
+	clock        Clock
+	location     *time.Location
+	runOnStart   bool
+	initialDelay time.Duration
+	jitter       time.Duration
+	reschedule   chan struct{}
+	workflow     *Workflow
+	hooks        jobHooks
+	leaseTTL     time.Duration
+	retry        *RetryPolicy
+	timeout      time.Duration
+	overlap      OverlapPolicy
+	misfire      MisfirePolicy
+	settings     *jobSettings
+	paused       bool
+
+	defaultSchedule Schedule
+	stats           JobStats
+
+	maxPanics         int
+	consecutivePanics int
+	disabled          bool
+
+	nextRunAt    time.Time
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	lastResult   string
+	runCount     int64
+	failureCount int64
+}
+
+// JobOption configures a job when it is added to the scheduler
+type JobOption func(*Job)
+
+// WithLocation sets the time zone cron expressions are evaluated in
+func WithLocation(loc *time.Location) JobOption {
+	return func(j *Job) {
+		if loc != nil {
+			j.location = loc
+		}
+	}
+}
+
+// WithRunOnStart sets whether the job runs as soon as it is started;
+// interval jobs default to true, other jobs to false
+func WithRunOnStart(runOnStart bool) JobOption {
+	return func(j *Job) {
+		j.runOnStart = runOnStart
+	}
+}
+
+// WithInitialDelay postpones the first activation after the job starts
+func WithInitialDelay(delay time.Duration) JobOption {
+	return func(j *Job) {
+		j.initialDelay = delay
+	}
+}
+
+// WithJitter delays every activation by a random duration up to jitter,
+// spreading load when many clients share a schedule
+func WithJitter(jitter time.Duration) JobOption {
+	return func(j *Job) {
+		j.jitter = jitter
+	}
 }
 
 // Scheduler manages multiple cron jobs
 type Scheduler struct {
-	jobs []*Job
-	mu   sync.RWMutex
-	ctx  context.Context
+	jobs    []*Job
+	mu      sync.RWMutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	hooks   jobHooks
+	clock   Clock
+	started bool
+	closed  bool
+
+	// applyMu serializes ApplySettings calls
+	applyMu sync.Mutex
+}
+
+// SchedulerOption configures a scheduler when it is created
+type SchedulerOption func(*Scheduler)
+
+// WithClock sets the time source of the scheduler and of every job added
+// to it, the default is RealClock
+func WithClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		if clock != nil {
+			s.clock = clock
+		}
+	}
 }
 
 // NewScheduler creates a new cron job scheduler
-func NewScheduler(ctx context.Context) *Scheduler {
-	return &Scheduler{
-		jobs: make([]*Job, 0),
-		ctx:  ctx,
+func NewScheduler(ctx context.Context, opts ...SchedulerOption) *Scheduler {
+	ctx, cancel := context.WithCancel(ctx)
+
+	scheduler := &Scheduler{
+		jobs:   make([]*Job, 0),
+		ctx:    ctx,
+		cancel: cancel,
+		clock:  RealClock,
+		hooks:  jobHooks{owner: helper.InstanceID()},
 	}
+
+	for _, opt := range opts {
+		opt(scheduler)
+	}
+
+	return scheduler
 }
 
-// AddJob adds a new job to the scheduler
-func (s *Scheduler) AddJob(name string, interval time.Duration, fn JobFunc) *Job {
+// AddJob adds a new job running every interval, starting immediately
+func (s *Scheduler) AddJob(name string, interval time.Duration, fn JobFunc, opts ...JobOption) (*Job, error) {
+	if err := validateSchedule(Every(interval)); err != nil {
+		return nil, fmt.Errorf("invalid interval for job '%s': %w", name, err)
+	}
+
+	opts = append([]JobOption{WithRunOnStart(true)}, opts...)
+	job := newJob(name, Every(interval), fn, opts...)
+
+	if err := s.addJob(job); err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Cron job '%s' added with interval: %v", name, interval)
+
+	return job, nil
+}
+
+// AddCronJob adds a new job running on a cron expression, see ParseCronInLocation
+func (s *Scheduler) AddCronJob(name string, expr string, fn JobFunc, opts ...JobOption) (*Job, error) {
+	job := newJob(name, nil, fn, opts...)
+
+	schedule, err := ParseCronInLocation(expr, job.location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule for job '%s': %w", name, err)
+	}
+	job.setSchedule(schedule)
+
+	if err := s.addJob(job); err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Cron job '%s' added with schedule: %s", name, schedule)
+
+	return job, nil
+}
+
+// AddScheduledJob adds a new job running on a custom schedule
+func (s *Scheduler) AddScheduledJob(name string, schedule Schedule, fn JobFunc, opts ...JobOption) (*Job, error) {
+	if schedule == nil {
+		return nil, fmt.Errorf("missing schedule for job '%s'", name)
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("invalid schedule for job '%s': %w", name, err)
+	}
+
+	job := newJob(name, schedule, fn, opts...)
+
+	if err := s.addJob(job); err != nil {
+		return nil, err
+	}
+	logger.Info.Printf("Cron job '%s' added with schedule: %s", name, schedule)
+
+	return job, nil
+}
+
+// RemoveJob stops a job and removes it from the scheduler
+func (s *Scheduler) RemoveJob(jobName string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	job := &Job{
-		Name:     name,
-		Interval: interval,
-		Fn:       fn,
-		stopChan: make(chan struct{}),
-		running:  false,
+	for i, job := range s.jobs {
+		if job.Name == jobName {
+			if job.IsRunning() {
+				if err := job.Stop(); err != nil {
+					return err
+				}
+			}
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			logger.Info.Printf("Cron job '%s' removed", jobName)
+			return nil
+		}
 	}
 
-	s.jobs = append(s.jobs, job)
-	logger.Info.Printf("Cron job '%s' added with interval: %v", name, interval)
+	return fmt.Errorf("%w: '%s'", ErrJobNotFound, jobName)
+}
 
-	return job
+// UpdateSchedule replaces the schedule of a job; a running job picks up the
+// new schedule immediately
+func (s *Scheduler) UpdateSchedule(jobName string, schedule Schedule) error {
+	if schedule == nil {
+		return fmt.Errorf("missing schedule for job '%s'", jobName)
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return fmt.Errorf("invalid schedule for job '%s': %w", jobName, err)
+	}
+
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+
+	job.setSchedule(schedule)
+	logger.Info.Printf("Cron job '%s' rescheduled to: %s", jobName, schedule)
+	return nil
 }
 
-// Start starts a specific job
-func (s *Scheduler) Start(jobName string) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// TriggerNow runs a job immediately, outside of its schedule
+func (s *Scheduler) TriggerNow(jobName string) error {
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+	if s.isClosed() {
+		return ErrSchedulerClosed
+	}
 
+	job.Trigger(s.ctx)
+	return nil
+}
+
+// SetRecorder sets where the runs of every job are recorded
+func (s *Scheduler) SetRecorder(recorder RunRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks.recorder = recorder
 	for _, job := range s.jobs {
-		if job.Name == jobName {
-			return job.Start(s.ctx)
+		job.setHooks(s.hooks)
+	}
+}
+
+// addJob registers a job, starting it right away when the scheduler has
+// already been started
+func (s *Scheduler) addJob(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSchedulerClosed
+	}
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("%w: '%s'", ErrJobExists, job.Name)
 		}
 	}
 
-	return fmt.Errorf("job '%s' not found", jobName)
+	job.clock = s.clock
+	job.defaultSchedule = job.GetSchedule()
+	if job.workflow != nil {
+		job.workflow.setClock(s.clock)
+	}
+	job.setHooks(s.hooks)
+	s.jobs = append(s.jobs, job)
+
+	if s.started {
+		return job.Start(s.ctx)
+	}
+	return nil
 }
 
-// Stop stops a specific job
-func (s *Scheduler) Stop(jobName string) error {
+// find returns the job with the given name
+func (s *Scheduler) find(jobName string) (*Job, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, job := range s.jobs {
 		if job.Name == jobName {
-			return job.Stop()
+			return job, nil
 		}
 	}
 
-	return fmt.Errorf("job '%s' not found", jobName)
+	return nil, fmt.Errorf("%w: '%s'", ErrJobNotFound, jobName)
+}
+
+func newJob(name string, schedule Schedule, fn JobFunc, opts ...JobOption) *Job {
+	job := &Job{
+		Name:       name,
+		Fn:         fn,
+		stopChan:   make(chan struct{}),
+		running:    false,
+		clock:      RealClock,
+		location:   time.Local,
+		leaseTTL:   DefaultLeaseTTL,
+		reschedule: make(chan struct{}, 1),
+	}
+	job.setSchedule(schedule)
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	return job
+}
+
+// Start starts a specific job
+func (s *Scheduler) Start(jobName string) error {
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+	if s.isClosed() {
+		return ErrSchedulerClosed
+	}
+
+	return job.Start(s.ctx)
+}
+
+// Stop stops a specific job
+func (s *Scheduler) Stop(jobName string) error {
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+
+	return job.Stop()
 }
 
-// StartAll starts all registered jobs
+// StartAll starts all registered jobs; jobs added afterwards start as soon
+// as they are added
 func (s *Scheduler) StartAll() {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		logger.Error.Printf("Cannot start jobs: %v", ErrSchedulerClosed)
+		return
+	}
+
+	s.started = true
 
 	for _, job := range s.jobs {
+		if job.IsPaused() {
+			continue
+		}
 		if err := job.Start(s.ctx); err != nil {
 			logger.Error.Printf("Failed to start job '%s': %v", job.Name, err)
 		}
@@ -97,10 +388,14 @@ func (s *Scheduler) StartAll() {
 
 // StopAll stops all running jobs
 func (s *Scheduler) StopAll() {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	s.started = false
 	for _, job := range s.jobs {
+		if !job.IsRunning() {
+			continue
+		}
 		if err := job.Stop(); err != nil {
 			logger.Error.Printf("Failed to stop job '%s': %v", job.Name, err)
 		}
@@ -109,35 +404,38 @@ func (s *Scheduler) StopAll() {
 
 // GetJobStatus returns the status of a specific job
 func (s *Scheduler) GetJobStatus(jobName string) (bool, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	for _, job := range s.jobs {
-		if job.Name == jobName {
-			return job.IsRunning(), nil
-		}
+	job, err := s.find(jobName)
+	if err != nil {
+		return false, err
 	}
 
-	return false, fmt.Errorf("job '%s' not found", jobName)
+	return job.IsRunning(), nil
 }
 
-// ListJobs returns all registered jobs with their status
-func (s *Scheduler) ListJobs() []map[string]interface{} {
+// ListJobs returns the status of all registered jobs
+func (s *Scheduler) ListJobs() []JobStatus {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	jobList := make([]map[string]interface{}, 0, len(s.jobs))
+	jobList := make([]JobStatus, 0, len(s.jobs))
 	for _, job := range s.jobs {
-		jobList = append(jobList, map[string]interface{}{
-			"name":     job.Name,
-			"interval": job.Interval.String(),
-			"running":  job.IsRunning(),
-		})
+		jobList = append(jobList, job.Status())
 	}
 
 	return jobList
 }
 
+// GetJob returns the status of a specific job
+func (s *Scheduler) GetJob(jobName string) (*JobStatus, error) {
+	job, err := s.find(jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := job.Status()
+	return &status, nil
+}
+
 // Start starts the job execution
 func (j *Job) Start(ctx context.Context) error {
 	j.mu.Lock()
@@ -145,13 +443,20 @@ func (j *Job) Start(ctx context.Context) error {
 		j.mu.Unlock()
 		return fmt.Errorf("job '%s' is already running", j.Name)
 	}
+	if j.paused {
+		j.mu.Unlock()
+		return fmt.Errorf("job '%s' is paused", j.Name)
+	}
 	j.running = true
+	j.disabled = false
+	j.consecutivePanics = 0
 	j.stopChan = make(chan struct{})
+	stop := j.stopChan
 	j.mu.Unlock()
 
-	logger.Info.Printf("Starting cron job '%s' with interval: %v", j.Name, j.Interval)
+	logger.Info.Printf("Starting cron job '%s' with schedule: %s", j.Name, j.GetSchedule())
 
-	go j.run(ctx)
+	go j.run(ctx, stop)
 
 	return nil
 }
@@ -168,10 +473,68 @@ func (j *Job) Stop() error {
 	logger.Info.Printf("Stopping cron job '%s'", j.Name)
 	close(j.stopChan)
 	j.running = false
+	j.stats.Pending = 0
 
 	return nil
 }
 
+// Trigger runs the job once now, following its overlap policy
+func (j *Job) Trigger(ctx context.Context) {
+	j.mu.RLock()
+	stop := j.stopChan
+	if !j.running {
+		// A stopped job still runs once; the fresh channel is never closed
+		stop = make(chan struct{})
+	}
+	j.mu.RUnlock()
+
+	logger.Info.Printf("Triggering cron job '%s'", j.Name)
+	j.dispatch(ctx, stop)
+}
+
+// GetSchedule returns the current schedule of the job
+func (j *Job) GetSchedule() Schedule {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.Schedule
+}
+
+// GetInterval returns the interval of the job, or 0 for cron schedules
+func (j *Job) GetInterval() time.Duration {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.Interval
+}
+
+// setSchedule replaces the schedule and wakes up the run loop
+func (j *Job) setSchedule(schedule Schedule) {
+	j.mu.Lock()
+	j.Schedule = schedule
+	j.Interval = 0
+	if interval, ok := schedule.(*IntervalSchedule); ok {
+		j.Interval = interval.Interval
+	}
+	j.mu.Unlock()
+
+	select {
+	case j.reschedule <- struct{}{}:
+	default:
+	}
+}
+
+func (j *Job) setHooks(hooks jobHooks) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.hooks = hooks
+}
+
+// Stats returns the execution counters of the job
+func (j *Job) Stats() JobStats {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.stats
+}
+
 // IsRunning returns whether the job is currently running
 func (j *Job) IsRunning() bool {
 	j.mu.RLock()
@@ -179,39 +542,258 @@ func (j *Job) IsRunning() bool {
 	return j.running
 }
 
-// run executes the job at specified intervals
-func (j *Job) run(ctx context.Context) {
-	ticker := time.NewTicker(j.Interval)
-	defer ticker.Stop()
-
-	// Execute immediately on start
-	j.execute(ctx)
+// run executes the job on its schedule
+func (j *Job) run(ctx context.Context, stop <-chan struct{}) {
+	// Drop reschedule signals sent before the job started
+	select {
+	case <-j.reschedule:
+	default:
+	}
 
-	for {
+	if j.initialDelay > 0 {
+		timer := j.clock.NewTimer(j.initialDelay)
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			logger.Info.Printf("Context cancelled, stopping job '%s'", j.Name)
 			return
-		case <-j.stopChan:
+		case <-stop:
+			timer.Stop()
 			logger.Info.Printf("Job '%s' stopped", j.Name)
 			return
-		case <-ticker.C:
-			j.execute(ctx)
+		case <-timer.C():
+		}
+	}
+
+	// Resume from the last activation handled, by this or a previous app
+	// run, so activations missed meanwhile go through the misfire policy
+	last, restored := j.loadLastRun()
+	if j.runOnStart {
+		j.dispatch(ctx, stop)
+		last = j.clock.Now()
+		j.saveLastRun(last)
+	} else if !restored {
+		last = j.clock.Now()
+	}
+
+	for {
+		next := j.GetSchedule().Next(last.In(j.location))
+		if next.IsZero() {
+			logger.Warning.Printf("Job '%s' has no upcoming runs, stopping", j.Name)
+			j.Stop()
+			return
+		}
+
+		j.mu.Lock()
+		j.nextRunAt = next
+		j.mu.Unlock()
+
+		switch j.wait(ctx, stop, next) {
+		case waitStopped:
+			return
+		case waitRescheduled:
+			last = j.clock.Now()
+		case waitClockChanged:
+			if now := j.clock.Now(); now.Before(last) {
+				last = now
+			}
+		case waitDue:
+			last = j.activate(ctx, stop, next)
 		}
 	}
 }
 
-// execute runs the job function and logs any errors
-func (j *Job) execute(ctx context.Context) {
-	logger.Debug.Printf("Executing job '%s'", j.Name)
+func (j *Job) randomJitter() time.Duration {
+	if j.jitter <= 0 {
+		return 0
+	}
+	return rand.N(j.jitter)
+}
+
+// dispatch starts an activation in the background according to the overlap
+// policy, so a slow run never delays the schedule
+func (j *Job) dispatch(ctx context.Context, stop <-chan struct{}) {
+	j.mu.Lock()
 
-	start := time.Now()
-	err := j.Fn(ctx)
-	duration := time.Since(start)
+	if ctx.Err() != nil {
+		j.mu.Unlock()
+		return
+	}
+
+	if j.stats.InFlight == 0 || j.overlap == OverlapAllow {
+		j.stats.InFlight++
+		j.runs.Add(1)
+		j.mu.Unlock()
+		go j.work(ctx, stop)
+		return
+	}
+
+	if j.overlap == OverlapQueue && j.stats.Pending < maxPendingRuns {
+		j.stats.Pending++
+		j.stats.Queued++
+		j.mu.Unlock()
+		logger.Info.Printf("Job '%s' is still running, activation queued", j.Name)
+		return
+	}
+
+	j.stats.Skipped++
+	j.stats.LastSkippedAt = j.clock.Now()
+	j.mu.Unlock()
+	logger.Warning.Printf("Job '%s' is still running, activation skipped", j.Name)
+}
+
+// work executes an activation and then any activations queued meanwhile
+func (j *Job) work(ctx context.Context, stop <-chan struct{}) {
+	for {
+		j.executeExclusive(ctx, stop)
+
+		j.mu.Lock()
+		if j.stats.Pending > 0 && j.running && ctx.Err() == nil {
+			j.stats.Pending--
+			j.mu.Unlock()
+			continue
+		}
+		j.stats.InFlight--
+		j.mu.Unlock()
+		j.runs.Done()
+		return
+	}
+}
+
+// execute runs the job function, retrying failed attempts according to
+// the job retry policy
+func (j *Job) execute(ctx context.Context, stop <-chan struct{}) {
+	for attempt := 1; ; attempt++ {
+		err := j.attempt(ctx, attempt)
+		if !j.retry.shouldRetry(attempt, err) {
+			return
+		}
+
+		delay := j.retry.Backoff.Delay(attempt)
+		logger.Warning.Printf("Retrying job '%s' in %v (attempt %d of %d)", j.Name, delay, attempt+1, j.retry.MaxAttempts)
+
+		timer := j.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// attempt runs the job function once, logs and records the outcome
+func (j *Job) attempt(ctx context.Context, attempt int) error {
+	logger.Debug.Printf("Executing job '%s' (attempt %d)", j.Name, attempt)
+
+	start := j.clock.Now()
+
+	j.mu.Lock()
+	j.stats.Executions++
+	j.lastRun = start
+	j.mu.Unlock()
+
+	j.emit(Event{Type: EventStarted, Job: j.Name, Attempt: attempt, StartedAt: start})
+
+	result := &runResult{}
+	err := j.call(context.WithValue(ctx, runResultKey{}, result))
+	duration := j.clock.Now().Sub(start)
+	summary := result.get()
+
+	j.mu.Lock()
+	j.runCount++
+	j.lastDuration = duration
+	j.lastError = ""
+	j.lastResult = summary
+	if err != nil {
+		j.failureCount++
+		j.lastError = err.Error()
+	}
+	j.mu.Unlock()
+
+	event := Event{Type: EventSucceeded, Job: j.Name, Attempt: attempt, StartedAt: start, Duration: duration, Result: summary}
+	if err != nil {
+		event.Type = EventFailed
+		event.Error = err.Error()
+	}
+	j.emit(event)
 
 	if err != nil {
 		logger.Error.Printf("Job '%s' failed after %v: %v", j.Name, duration, err)
 	} else {
 		logger.Debug.Printf("Job '%s' completed successfully in %v", j.Name, duration)
 	}
+
+	j.record(start, duration, attempt, summary, err)
+	j.trackPanics(err)
+	return err
+}
+
+// call invokes the job function. Once the timeout expires the run is
+// reported as timed out, but call still waits for the function to return so
+// it never overlaps the next run.
+func (j *Job) call(ctx context.Context) error {
+	if j.timeout <= 0 {
+		return j.safeCall(ctx)
+	}
+
+	runCtx, cancel := withTimeout(j.clock, ctx, j.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- j.safeCall(runCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-runCtx.Done():
+		// The function is waited for, so Shutdown sees it as in flight
+		// until it really returned
+		if ctx.Err() != nil {
+			<-done
+			return ctx.Err()
+		}
+
+		j.mu.Lock()
+		j.stats.TimedOut++
+		j.mu.Unlock()
+
+		logger.Warning.Printf("Job '%s' timed out after %v, waiting for it to return", j.Name, j.timeout)
+		<-done
+		return fmt.Errorf("job '%s' timed out after %v: %w", j.Name, j.timeout, context.DeadlineExceeded)
+	}
+}
+
+// record stores a run through the recorder, if any
+func (j *Job) record(start time.Time, duration time.Duration, attempt int, summary string, err error) {
+	j.mu.RLock()
+	recorder := j.hooks.recorder
+	j.mu.RUnlock()
+
+	if recorder == nil {
+		return
+	}
+
+	run := &models.JobRun{
+		JobName:    j.Name,
+		Status:     enum.JOB_SUCCESS,
+		Attempt:    attempt,
+		StartedAt:  start,
+		FinishedAt: start.Add(duration),
+		DurationMs: duration.Milliseconds(),
+		Result:     summary,
+	}
+	if err != nil {
+		run.Status = enum.JOB_FAILED
+		run.Error = err.Error()
+	}
+
+	if err := recorder.RecordRun(run); err != nil {
+		logger.Error.Printf("Failed to record run of job '%s': %v", j.Name, err)
+	}
 }
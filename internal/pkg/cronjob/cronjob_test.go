@@ -0,0 +1,409 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// waitTimeout bounds how long a test waits for a goroutine of the scheduler
+const waitTimeout = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	logger.Setup()
+	os.Exit(m.Run())
+}
+
+func newTestScheduler(t *testing.T, now time.Time) (*Scheduler, *FakeClock) {
+	t.Helper()
+
+	clock := NewFakeClock(now)
+	scheduler := NewScheduler(context.Background(), WithClock(clock))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+		defer cancel()
+		scheduler.Shutdown(ctx)
+	})
+	return scheduler, clock
+}
+
+// recordRuns returns a job function sending the fake time of every run
+func recordRuns(clock *FakeClock) (JobFunc, <-chan time.Time) {
+	runs := make(chan time.Time, 16)
+	return func(ctx context.Context) error {
+		runs <- clock.Now()
+		return nil
+	}, runs
+}
+
+func expectRun(t *testing.T, runs <-chan time.Time, want time.Time) {
+	t.Helper()
+
+	select {
+	case got := <-runs:
+		if !got.Equal(want) {
+			t.Fatalf("job ran at %v, want %v", got, want)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatalf("job did not run at %v", want)
+	}
+}
+
+func expectNoRun(t *testing.T, runs <-chan time.Time) {
+	t.Helper()
+
+	select {
+	case got := <-runs:
+		t.Fatalf("job ran unexpectedly at %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// waitSignal waits until ch is closed or receives
+func waitSignal(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(waitTimeout):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// waitFor polls cond until it holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitRuns waits until the in-flight runs of job returned
+func waitRuns(t *testing.T, job *Job) {
+	t.Helper()
+
+	finished := make(chan struct{})
+	go func() {
+		job.runs.Wait()
+		close(finished)
+	}()
+	waitSignal(t, finished, "the runs of "+job.Name+" to finish")
+}
+
+// waitArmed waits until the run loop of job waits for the activation at
+// next, so advancing the clock afterwards reaches its timers
+func waitArmed(t *testing.T, clock *FakeClock, job *Job, next time.Time) {
+	t.Helper()
+
+	waitFor(t, "the activation at "+next.Format(time.RFC3339), func() bool {
+		job.mu.RLock()
+		defer job.mu.RUnlock()
+		return job.nextRunAt.Equal(next)
+	})
+	clock.BlockUntil(2)
+}
+
+func TestIntervalJobFiringTimes(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	fn, runs := recordRuns(clock)
+	if _, err := scheduler.AddJob("interval", 10*time.Second, fn); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	// Interval jobs run once on start
+	expectRun(t, runs, start)
+
+	for i := 1; i <= 3; i++ {
+		// The run loop waits on the activation timer and the clock check
+		clock.BlockUntil(2)
+		clock.Advance(9 * time.Second)
+		expectNoRun(t, runs)
+
+		clock.Advance(time.Second)
+		expectRun(t, runs, start.Add(time.Duration(i)*10*time.Second))
+	}
+}
+
+func TestCronJobFiringTimes(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 5, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	fn, runs := recordRuns(clock)
+	if _, err := scheduler.AddCronJob("cron", "*/15 * * * * *", fn, WithLocation(time.UTC)); err != nil {
+		t.Fatalf("AddCronJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	clock.BlockUntil(2)
+	expectNoRun(t, runs)
+
+	clock.Advance(10 * time.Second)
+	expectRun(t, runs, time.Date(2026, 1, 5, 10, 0, 15, 0, time.UTC))
+
+	clock.BlockUntil(2)
+	clock.Advance(15 * time.Second)
+	expectRun(t, runs, time.Date(2026, 1, 5, 10, 0, 30, 0, time.UTC))
+
+	clock.BlockUntil(2)
+	clock.Advance(30 * time.Second)
+	expectRun(t, runs, time.Date(2026, 1, 5, 10, 1, 0, 0, time.UTC))
+}
+
+func TestStopWhileRunInFlight(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job, err := scheduler.AddJob("slow", 10*time.Second, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	clock.BlockUntil(2)
+	clock.Advance(10 * time.Second)
+	waitSignal(t, started, "the run to start")
+
+	if err := scheduler.Stop("slow"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if job.IsRunning() {
+		t.Fatal("job is still running after Stop")
+	}
+	if stats := job.Stats(); stats.InFlight != 1 {
+		t.Fatalf("InFlight = %d after Stop, want 1", stats.InFlight)
+	}
+
+	// A stopped job does not fire anymore, even once the run returned
+	close(release)
+	finished := make(chan struct{})
+	go func() {
+		job.runs.Wait()
+		close(finished)
+	}()
+	waitSignal(t, finished, "the run to finish")
+
+	clock.Advance(time.Minute)
+	stats := job.Stats()
+	if stats.InFlight != 0 || stats.Executions != 1 {
+		t.Fatalf("stats after Stop = %+v, want one execution and none in flight", stats)
+	}
+}
+
+func TestShutdownWaitsForRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []JobOption
+	}{
+		{name: "without timeout"},
+		{name: "with timeout", opts: []JobOption{WithTimeout(5 * time.Second)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+			scheduler, clock := newTestScheduler(t, start)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			opts := append([]JobOption{WithRunOnStart(false)}, tt.opts...)
+			if _, err := scheduler.AddJob("slow", 10*time.Second, func(ctx context.Context) error {
+				close(started)
+				// The function ignores the cancellation until released
+				<-release
+				return ctx.Err()
+			}, opts...); err != nil {
+				t.Fatalf("AddJob: %v", err)
+			}
+			scheduler.StartAll()
+
+			clock.BlockUntil(2)
+			clock.Advance(10 * time.Second)
+			waitSignal(t, started, "the run to start")
+
+			type result struct {
+				report *ShutdownReport
+				err    error
+			}
+			results := make(chan result, 1)
+			go func() {
+				report, err := scheduler.Shutdown(context.Background())
+				results <- result{report, err}
+			}()
+
+			select {
+			case <-results:
+				t.Fatal("Shutdown returned while the job function was running")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			close(release)
+			select {
+			case res := <-results:
+				if res.err != nil {
+					t.Fatalf("Shutdown: %v", res.err)
+				}
+				if len(res.report.Completed) != 1 || res.report.Completed[0] != "slow" || len(res.report.Abandoned) != 0 {
+					t.Fatalf("Shutdown report = %+v, want slow completed", res.report)
+				}
+			case <-time.After(waitTimeout):
+				t.Fatal("Shutdown did not return after the job function returned")
+			}
+		})
+	}
+}
+
+func TestShutdownAbandonsRunsPastDeadline(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := scheduler.AddJob("stuck", 10*time.Second, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}, WithRunOnStart(false)); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	clock.BlockUntil(2)
+	clock.Advance(10 * time.Second)
+	waitSignal(t, started, "the run to start")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report, err := scheduler.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(report.Abandoned) != 1 || report.Abandoned[0] != "stuck" {
+		t.Fatalf("Shutdown report = %+v, want stuck abandoned", report)
+	}
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	fn, runs := recordRuns(clock)
+	job, err := scheduler.AddJob("interval", 10*time.Second, fn, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+	waitArmed(t, clock, job, start.Add(10*time.Second))
+
+	if err := scheduler.UpdateSchedule("interval", Every(time.Minute)); err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	if got := job.GetInterval(); got != time.Minute {
+		t.Fatalf("interval = %v after UpdateSchedule, want %v", got, time.Minute)
+	}
+
+	// The new schedule counts from the update
+	waitArmed(t, clock, job, start.Add(time.Minute))
+	clock.Advance(10 * time.Second)
+	expectNoRun(t, runs)
+	clock.Advance(50 * time.Second)
+	expectRun(t, runs, start.Add(time.Minute))
+
+	cron, err := ParseCronInLocation("0 0 * * * *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.UpdateSchedule("interval", cron); err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	if got := job.GetInterval(); got != 0 {
+		t.Fatalf("interval = %v for a cron schedule, want 0", got)
+	}
+	waitArmed(t, clock, job, start.Add(time.Hour))
+	clock.Advance(59 * time.Minute)
+	expectRun(t, runs, start.Add(time.Hour))
+
+	invalid := []struct {
+		name     string
+		job      string
+		schedule Schedule
+		want     error
+	}{
+		{name: "missing schedule", job: "interval"},
+		{name: "zero interval", job: "interval", schedule: Every(0)},
+		{name: "unknown job", job: "missing", schedule: Every(time.Minute), want: ErrJobNotFound},
+	}
+	for _, tt := range invalid {
+		err := scheduler.UpdateSchedule(tt.job, tt.schedule)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Fatalf("%s: UpdateSchedule error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestConcurrentControl(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	job, err := scheduler.AddJob("busy", time.Second, func(ctx context.Context) error {
+		return nil
+	}, WithOverlap(OverlapQueue))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	const iterations = 200
+	var wg sync.WaitGroup
+	calls := []func(i int){
+		func(int) { scheduler.Start("busy") },
+		func(int) { scheduler.Stop("busy") },
+		func(int) { scheduler.TriggerNow("busy") },
+		func(i int) { scheduler.UpdateSchedule("busy", Every(time.Duration(i%5+1)*time.Second)) },
+		func(int) { scheduler.ListJobs() },
+		func(int) { job.Stats() },
+		func(int) { clock.Advance(time.Second) },
+	}
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call func(int)) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				call(i)
+			}
+		}(call)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	report, err := scheduler.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown: %v (%+v)", err, report)
+	}
+	if stats := job.Stats(); stats.InFlight != 0 || stats.Executions == 0 {
+		t.Fatalf("stats after Shutdown = %+v, want executions and none in flight", stats)
+	}
+	if err := scheduler.TriggerNow("busy"); !errors.Is(err, ErrSchedulerClosed) {
+		t.Fatalf("TriggerNow after Shutdown = %v, want %v", err, ErrSchedulerClosed)
+	}
+}
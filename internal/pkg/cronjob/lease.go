@@ -0,0 +1,154 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// DefaultLeaseTTL is how long a job lease stays valid without renewal; a
+// lease of a crashed instance is taken over once it expires
+const DefaultLeaseTTL = time.Minute
+
+// leaseHandoverMargin is how long before the next activation a lease kept
+// after a run expires, so the instance running that activation can take it
+const leaseHandoverMargin = time.Second
+
+// ErrLeaseLost cancels a run whose lease was taken over by another instance
+var ErrLeaseLost = errors.New("job lease taken over by another instance")
+
+// Locker grants leases so that only one app instance executes a job at a
+// time. Acquire succeeds when the lease is free, expired or already held by
+// owner, and extends it by ttl.
+type Locker interface {
+	Acquire(jobName, owner string, ttl time.Duration) (bool, error)
+	Release(jobName, owner string) error
+}
+
+// jobHooks are the scheduler-wide collaborators shared with every job
+type jobHooks struct {
+	recorder RunRecorder
+	onEvent  EventHandler
+	locker   Locker
+	store    ScheduleStore
+	owner    string
+}
+
+// WithLeaseTTL sets how long the lease of the job lasts between renewals;
+// a zero ttl lets every app instance run the job
+func WithLeaseTTL(ttl time.Duration) JobOption {
+	return func(j *Job) {
+		j.leaseTTL = ttl
+	}
+}
+
+// SetLocker sets the locker used to run every job in a single app instance
+func (s *Scheduler) SetLocker(locker Locker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks.locker = locker
+	for _, job := range s.jobs {
+		job.setHooks(s.hooks)
+	}
+}
+
+// InstanceID returns the lease owner identifying this app instance
+func (s *Scheduler) InstanceID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hooks.owner
+}
+
+// executeExclusive runs an activation while holding the job lease, skipping
+// it when another instance holds the lease. The lease is kept until just
+// before the next activation, so instances whose timers fire later skip
+// this activation instead of running it again.
+func (j *Job) executeExclusive(ctx context.Context, stop <-chan struct{}) {
+	j.mu.RLock()
+	locker, owner, ttl := j.hooks.locker, j.hooks.owner, j.leaseTTL
+	j.mu.RUnlock()
+
+	if locker == nil || ttl <= 0 {
+		j.execute(ctx, stop)
+		return
+	}
+
+	acquired, err := locker.Acquire(j.Name, owner, ttl)
+	if err != nil {
+		logger.Error.Printf("Failed to acquire lease of job '%s', activation skipped: %v", j.Name, err)
+		return
+	}
+	if !acquired {
+		j.mu.Lock()
+		j.stats.LeaseSkipped++
+		j.mu.Unlock()
+		logger.Info.Printf("Job '%s' is running in another instance, activation skipped", j.Name)
+		return
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		j.renewLease(locker, owner, ttl, done, cancel)
+	}()
+
+	j.execute(runCtx, stop)
+
+	close(done)
+	<-renewed
+	if errors.Is(context.Cause(runCtx), ErrLeaseLost) {
+		return
+	}
+
+	if hold := j.leaseHold(); hold > 0 {
+		if _, err := locker.Acquire(j.Name, owner, hold); err != nil {
+			logger.Warning.Printf("Failed to keep lease of job '%s': %v", j.Name, err)
+		}
+		return
+	}
+	if err := locker.Release(j.Name, owner); err != nil {
+		logger.Warning.Printf("Failed to release lease of job '%s': %v", j.Name, err)
+	}
+}
+
+// leaseHold returns how long the lease is kept after a run: until shortly
+// before the next activation, or 0 when the job never fires again
+func (j *Job) leaseHold() time.Duration {
+	now := j.clock.Now()
+	next := j.GetSchedule().Next(now.In(j.location))
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now) - leaseHandoverMargin
+}
+
+// renewLease extends the lease every third of its ttl until done is closed;
+// the run is cancelled with ErrLeaseLost when another instance took it over
+func (j *Job) renewLease(locker Locker, owner string, ttl time.Duration, done <-chan struct{}, cancel context.CancelCauseFunc) {
+	for {
+		timer := j.clock.NewTimer(ttl / 3)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		acquired, err := locker.Acquire(j.Name, owner, ttl)
+		switch {
+		case err != nil:
+			logger.Warning.Printf("Failed to renew lease of job '%s': %v", j.Name, err)
+		case !acquired:
+			logger.Warning.Printf("Lease of job '%s' was taken over by another instance, cancelling the run", j.Name)
+			cancel(ErrLeaseLost)
+			return
+		}
+	}
+}
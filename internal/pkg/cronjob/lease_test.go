@@ -0,0 +1,201 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeLease struct {
+	owner   string
+	expires time.Time
+}
+
+// fakeLocker grants leases in memory, expiring them on the fake clock
+type fakeLocker struct {
+	mu     sync.Mutex
+	clock  *FakeClock
+	leases map[string]fakeLease
+}
+
+func newFakeLocker(clock *FakeClock) *fakeLocker {
+	return &fakeLocker{clock: clock, leases: make(map[string]fakeLease)}
+}
+
+func (l *fakeLocker) Acquire(jobName, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if lease, ok := l.leases[jobName]; ok && lease.owner != owner && now.Before(lease.expires) {
+		return false, nil
+	}
+	l.leases[jobName] = fakeLease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *fakeLocker) Release(jobName, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lease, ok := l.leases[jobName]; ok && lease.owner == owner {
+		delete(l.leases, jobName)
+	}
+	return nil
+}
+
+func (l *fakeLocker) lease(jobName string) (fakeLease, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lease, ok := l.leases[jobName]
+	return lease, ok
+}
+
+// onceSchedule fires once at a fixed time
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+func (s onceSchedule) String() string {
+	return "once at " + s.at.Format(time.RFC3339)
+}
+
+func TestLeaseHeldUntilNextActivation(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+	locker := newFakeLocker(clock)
+	scheduler.SetLocker(locker)
+
+	fn, runs := recordRuns(clock)
+	job, err := scheduler.AddJob("exclusive", 10*time.Second, fn, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	waitArmed(t, clock, job, start.Add(10*time.Second))
+	clock.Advance(10 * time.Second)
+	expectRun(t, runs, start.Add(10*time.Second))
+	waitRuns(t, job)
+
+	// Another instance firing late for the same activation is kept out
+	lease, ok := locker.lease("exclusive")
+	if !ok || lease.owner != scheduler.InstanceID() {
+		t.Fatalf("lease = %+v, want held by %s", lease, scheduler.InstanceID())
+	}
+	if want := start.Add(20*time.Second - leaseHandoverMargin); !lease.expires.Equal(want) {
+		t.Fatalf("lease expires at %v, want %v", lease.expires, want)
+	}
+	if acquired, _ := locker.Acquire("exclusive", "other", time.Minute); acquired {
+		t.Fatal("another instance acquired the lease before the next activation")
+	}
+
+	// The same instance takes it again for the next activation
+	waitArmed(t, clock, job, start.Add(20*time.Second))
+	clock.Advance(10 * time.Second)
+	expectRun(t, runs, start.Add(20*time.Second))
+}
+
+func TestLeaseReleasedWhenJobNeverFiresAgain(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+	locker := newFakeLocker(clock)
+	scheduler.SetLocker(locker)
+
+	fn, runs := recordRuns(clock)
+	job, err := scheduler.AddScheduledJob("once", onceSchedule{at: start.Add(time.Minute)}, fn)
+	if err != nil {
+		t.Fatalf("AddScheduledJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	waitArmed(t, clock, job, start.Add(time.Minute))
+	clock.Advance(time.Minute)
+	expectRun(t, runs, start.Add(time.Minute))
+	waitRuns(t, job)
+
+	if lease, ok := locker.lease("once"); ok {
+		t.Fatalf("lease = %+v after the last run, want released", lease)
+	}
+}
+
+func TestLeaseHeldByAnotherInstanceSkipsActivation(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+	locker := newFakeLocker(clock)
+	scheduler.SetLocker(locker)
+
+	fn, runs := recordRuns(clock)
+	job, err := scheduler.AddJob("exclusive", 10*time.Second, fn, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	locker.Acquire("exclusive", "other", 15*time.Second)
+	scheduler.StartAll()
+
+	waitArmed(t, clock, job, start.Add(10*time.Second))
+	clock.Advance(10 * time.Second)
+	waitFor(t, "the activation to be skipped", func() bool { return job.Stats().LeaseSkipped == 1 })
+	expectNoRun(t, runs)
+
+	// The lease of the other instance expired meanwhile
+	waitArmed(t, clock, job, start.Add(20*time.Second))
+	clock.Advance(10 * time.Second)
+	expectRun(t, runs, start.Add(20*time.Second))
+}
+
+func TestLeaseTakenOverCancelsRun(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+	locker := newFakeLocker(clock)
+	scheduler.SetLocker(locker)
+
+	started := make(chan struct{})
+	causes := make(chan error, 1)
+	job, err := scheduler.AddJob("exclusive", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+		return ctx.Err()
+	}, WithRunOnStart(false), WithLeaseTTL(30*time.Second))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	waitArmed(t, clock, job, start.Add(time.Hour))
+	clock.Advance(time.Hour)
+	waitSignal(t, started, "the run to start")
+
+	// Another instance took the lease, e.g. after this one was suspended
+	locker.mu.Lock()
+	locker.leases["exclusive"] = fakeLease{owner: "other", expires: clock.Now().Add(time.Hour)}
+	locker.mu.Unlock()
+
+	// The renewal timer, a third of the ttl, next to the run loop timers
+	clock.BlockUntil(3)
+	clock.Advance(10 * time.Second)
+
+	select {
+	case cause := <-causes:
+		if !errors.Is(cause, ErrLeaseLost) {
+			t.Fatalf("run cancelled with %v, want %v", cause, ErrLeaseLost)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("run was not cancelled after losing the lease")
+	}
+	waitRuns(t, job)
+
+	if lease, _ := locker.lease("exclusive"); lease.owner != "other" {
+		t.Fatalf("lease owner = %q after losing it, want other", lease.owner)
+	}
+}
@@ -0,0 +1,234 @@
+package cronjob
+
+import (
+	"context"
+	"time"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// MisfirePolicy decides what happens with activations missed while the
+// computer was asleep, the app was closed or the wall clock jumped forward
+type MisfirePolicy int
+
+const (
+	// MisfireSkip drops missed activations and waits for the next one
+	MisfireSkip MisfirePolicy = iota
+	// MisfireRunOnce runs the job once for all missed activations
+	MisfireRunOnce
+	// MisfireRunAll runs the job once per missed activation, up to
+	// maxPendingRuns + 1 runs
+	MisfireRunAll
+)
+
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireSkip:
+		return "skip"
+	case MisfireRunOnce:
+		return "run_once"
+	case MisfireRunAll:
+		return "run_all"
+	default:
+		return ""
+	}
+}
+
+const (
+	// misfireThreshold is how late, on top of the job jitter, an activation
+	// may fire before it counts as missed
+	misfireThreshold = time.Minute
+
+	// clockCheckInterval is how often a waiting job compares the wall clock
+	// with the monotonic clock to notice suspends and clock adjustments
+	clockCheckInterval = time.Minute
+
+	// clockJumpThreshold is the drift between both clocks treated as a jump
+	clockJumpThreshold = 5 * time.Second
+
+	// maxMisfireScan bounds how many missed activations are enumerated
+	maxMisfireScan = 10000
+)
+
+// ScheduleStore persists the last activation of every job so missed runs
+// are also caught up across app restarts
+type ScheduleStore interface {
+	// GetLastRun returns the zero time when the job never ran
+	GetLastRun(jobName string) (time.Time, error)
+	SaveLastRun(jobName string, at time.Time) error
+}
+
+// WithMisfirePolicy sets how missed activations are handled, the default
+// is MisfireSkip
+func WithMisfirePolicy(policy MisfirePolicy) JobOption {
+	return func(j *Job) {
+		j.misfire = policy
+	}
+}
+
+// SetScheduleStore sets where the last activation of every job is kept
+func (s *Scheduler) SetScheduleStore(store ScheduleStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks.store = store
+	for _, job := range s.jobs {
+		job.setHooks(s.hooks)
+	}
+}
+
+type waitResult int
+
+const (
+	waitDue waitResult = iota
+	waitStopped
+	waitRescheduled
+	waitClockChanged
+)
+
+// wait blocks until the activation at next is due. Timers measure
+// monotonic time, which stops while the computer sleeps and ignores wall
+// clock adjustments, so the wall clock is checked periodically as well.
+func (j *Job) wait(ctx context.Context, stop <-chan struct{}, next time.Time) waitResult {
+	deadline := next.Add(j.randomJitter())
+	timer := j.clock.NewTimer(deadline.Sub(j.clock.Now()))
+	defer timer.Stop()
+
+	for {
+		checkedAt := j.clock.Now()
+		check := j.clock.NewTimer(clockCheckInterval)
+
+		select {
+		case <-ctx.Done():
+			check.Stop()
+			logger.Info.Printf("Context cancelled, stopping job '%s'", j.Name)
+			return waitStopped
+		case <-stop:
+			check.Stop()
+			logger.Info.Printf("Job '%s' stopped", j.Name)
+			return waitStopped
+		case <-j.reschedule:
+			check.Stop()
+			return waitRescheduled
+		case <-timer.C():
+			check.Stop()
+			return waitDue
+		case <-check.C():
+		}
+
+		now := j.clock.Now()
+		if !now.Before(deadline) {
+			return waitDue
+		}
+
+		drift := now.Round(0).Sub(checkedAt.Round(0)) - now.Sub(checkedAt)
+		if now.Before(checkedAt) || drift > clockJumpThreshold || drift < -clockJumpThreshold {
+			logger.Warning.Printf("Wall clock jumped by %v, recomputing schedule of job '%s'", drift, j.Name)
+			return waitClockChanged
+		}
+	}
+}
+
+// activate runs the activation at next, applying the misfire policy when
+// it fires late, and returns the last activation handled
+func (j *Job) activate(ctx context.Context, stop <-chan struct{}, next time.Time) time.Time {
+	now := j.clock.Now()
+	if now.Sub(next) <= misfireThreshold+j.jitter {
+		j.dispatch(ctx, stop)
+		j.saveLastRun(next)
+		return next
+	}
+
+	missed, last := j.missedActivations(next, now)
+
+	switch j.misfire {
+	case MisfireRunOnce:
+		logger.Warning.Printf("Job '%s' missed %d activations since %v, running once", j.Name, missed, next)
+		j.dispatchN(ctx, stop, 1)
+	case MisfireRunAll:
+		logger.Warning.Printf("Job '%s' missed %d activations since %v, catching up", j.Name, missed, next)
+		j.dispatchN(ctx, stop, missed)
+	default:
+		logger.Warning.Printf("Job '%s' missed %d activations since %v, skipping", j.Name, missed, next)
+	}
+
+	j.saveLastRun(last)
+	return last
+}
+
+// missedActivations counts the activations from next up to now and
+// returns the latest of them
+func (j *Job) missedActivations(next, now time.Time) (int, time.Time) {
+	schedule := j.GetSchedule()
+
+	count, last := 0, next
+	for t := next; !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		if count == maxMisfireScan {
+			return count, now
+		}
+		count++
+		last = t
+	}
+	return count, last
+}
+
+// dispatchN starts an activation and queues n-1 more behind it, regardless
+// of the overlap policy
+func (j *Job) dispatchN(ctx context.Context, stop <-chan struct{}, n int) {
+	if n <= 0 {
+		return
+	}
+	j.dispatch(ctx, stop)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stats.InFlight == 0 {
+		return
+	}
+	extra := min(n-1, maxPendingRuns-j.stats.Pending)
+	if extra > 0 {
+		j.stats.Pending += extra
+		j.stats.Queued += int64(extra)
+	}
+}
+
+// loadLastRun returns the persisted last activation, if any
+func (j *Job) loadLastRun() (time.Time, bool) {
+	j.mu.RLock()
+	store := j.hooks.store
+	j.mu.RUnlock()
+
+	if store == nil {
+		return time.Time{}, false
+	}
+
+	last, err := store.GetLastRun(j.Name)
+	if err != nil {
+		logger.Error.Printf("Failed to load last run of job '%s': %v", j.Name, err)
+		return time.Time{}, false
+	}
+	if last.IsZero() {
+		return time.Time{}, false
+	}
+
+	// A last run in the future means the wall clock was moved back
+	if now := j.clock.Now(); last.After(now) {
+		return now, true
+	}
+	return last, true
+}
+
+// saveLastRun persists the last activation handled
+func (j *Job) saveLastRun(at time.Time) {
+	j.mu.RLock()
+	store := j.hooks.store
+	j.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+	if err := store.SaveLastRun(j.Name, at); err != nil {
+		logger.Error.Printf("Failed to save last run of job '%s': %v", j.Name, err)
+	}
+}
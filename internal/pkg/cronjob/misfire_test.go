@@ -0,0 +1,64 @@
+package cronjob
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMisfirePoliciesAfterClockJump(t *testing.T) {
+	tests := []struct {
+		policy   MisfirePolicy
+		wantRuns int
+	}{
+		{policy: MisfireSkip, wantRuns: 0},
+		{policy: MisfireRunOnce, wantRuns: 1},
+		// One run per missed activation, from start+10m to start+60m
+		{policy: MisfireRunAll, wantRuns: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+			scheduler, clock := newTestScheduler(t, start)
+
+			fn, runs := recordRuns(clock)
+			job, err := scheduler.AddJob("misfire", 10*time.Minute, fn,
+				WithRunOnStart(false), WithMisfirePolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("AddJob: %v", err)
+			}
+			scheduler.StartAll()
+			waitArmed(t, clock, job, start.Add(10*time.Minute))
+
+			// The wall clock jumps an hour ahead, e.g. after a suspend
+			clock.Set(start.Add(time.Hour))
+			for i := 0; i < tt.wantRuns; i++ {
+				expectRun(t, runs, start.Add(time.Hour))
+			}
+			expectNoRun(t, runs)
+
+			// The schedule continues after the last missed activation
+			waitArmed(t, clock, job, start.Add(70*time.Minute))
+			clock.Advance(10 * time.Minute)
+			expectRun(t, runs, start.Add(70*time.Minute))
+		})
+	}
+}
+
+func TestLateActivationWithinThresholdRuns(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	fn, runs := recordRuns(clock)
+	job, err := scheduler.AddJob("late", 10*time.Minute, fn, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+	waitArmed(t, clock, job, start.Add(10*time.Minute))
+
+	// Less than misfireThreshold late is not a misfire, even when skipping
+	clock.Set(start.Add(10*time.Minute + 30*time.Second))
+	expectRun(t, runs, start.Add(10*time.Minute+30*time.Second))
+	waitArmed(t, clock, job, start.Add(20*time.Minute))
+}
@@ -0,0 +1,66 @@
+package cronjob
+
+import (
+	"time"
+)
+
+// OverlapPolicy decides what happens when a job is due while a previous run
+// of the same job is still in progress
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops the activation and counts it as skipped
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the activation once the current run finishes, up to
+	// maxPendingRuns waiting activations
+	OverlapQueue
+	// OverlapAllow starts the activation concurrently
+	OverlapAllow
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapSkip:
+		return "skip"
+	case OverlapQueue:
+		return "queue"
+	case OverlapAllow:
+		return "allow"
+	default:
+		return ""
+	}
+}
+
+// maxPendingRuns bounds the activations OverlapQueue keeps waiting; further
+// activations are skipped
+const maxPendingRuns = 16
+
+// JobStats holds execution counters of a job
+type JobStats struct {
+	Executions    int64     `json:"executions"`
+	Skipped       int64     `json:"skipped"`
+	Queued        int64     `json:"queued"`
+	TimedOut      int64     `json:"timedOut"`
+	Panics        int64     `json:"panics"`
+	LeaseSkipped  int64     `json:"leaseSkipped"`
+	InFlight      int       `json:"inFlight"`
+	Pending       int       `json:"pending"`
+	LastSkippedAt time.Time `json:"lastSkippedAt,omitempty"`
+}
+
+// WithOverlap sets how overlapping activations are handled, the default
+// is OverlapSkip
+func WithOverlap(policy OverlapPolicy) JobOption {
+	return func(j *Job) {
+		j.overlap = policy
+	}
+}
+
+// WithTimeout bounds every attempt of the job; the job context is cancelled
+// when the timeout expires and the run is recorded as failed. The run keeps
+// counting as in flight until the job function returns.
+func WithTimeout(timeout time.Duration) JobOption {
+	return func(j *Job) {
+		j.timeout = timeout
+	}
+}
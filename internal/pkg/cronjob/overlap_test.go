@@ -0,0 +1,98 @@
+package cronjob
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingRun returns a job function signalling started and then waiting
+// until release is closed
+func blockingRun() (JobFunc, <-chan struct{}, chan struct{}) {
+	started := make(chan struct{}, 16)
+	release := make(chan struct{})
+	return func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, started, release
+}
+
+func TestOverlapPolicies(t *testing.T) {
+	tests := []struct {
+		policy       OverlapPolicy
+		wantInFlight int
+		wantRuns     int64
+		wantSkipped  int64
+		wantQueued   int64
+	}{
+		{policy: OverlapSkip, wantInFlight: 1, wantRuns: 1, wantSkipped: 2},
+		{policy: OverlapQueue, wantInFlight: 1, wantRuns: 3, wantQueued: 2},
+		{policy: OverlapAllow, wantInFlight: 3, wantRuns: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+			scheduler, clock := newTestScheduler(t, start)
+
+			fn, started, release := blockingRun()
+			job, err := scheduler.AddJob("slow", 10*time.Second, fn,
+				WithRunOnStart(false), WithOverlap(tt.policy))
+			if err != nil {
+				t.Fatalf("AddJob: %v", err)
+			}
+			scheduler.StartAll()
+
+			// Three activations while the first run is still going
+			for i := 1; i <= 3; i++ {
+				waitArmed(t, clock, job, start.Add(time.Duration(i)*10*time.Second))
+				clock.Advance(10 * time.Second)
+			}
+			waitArmed(t, clock, job, start.Add(40*time.Second))
+
+			stats := job.Stats()
+			if stats.InFlight != tt.wantInFlight || stats.Skipped != tt.wantSkipped || stats.Queued != tt.wantQueued {
+				t.Fatalf("stats while running = %+v, want %d in flight, %d skipped and %d queued",
+					stats, tt.wantInFlight, tt.wantSkipped, tt.wantQueued)
+			}
+
+			close(release)
+			for i := int64(0); i < tt.wantRuns; i++ {
+				waitSignal(t, started, "a run to start")
+			}
+			waitRuns(t, job)
+
+			stats = job.Stats()
+			if stats.Executions != tt.wantRuns || stats.InFlight != 0 || stats.Pending != 0 {
+				t.Fatalf("stats after the runs = %+v, want %d executions and none in flight or pending", stats, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestOverlapQueueIsBounded(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, _ := newTestScheduler(t, start)
+
+	fn, started, release := blockingRun()
+	job, err := scheduler.AddJob("slow", time.Hour, fn, WithRunOnStart(false), WithOverlap(OverlapQueue))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	for i := 0; i < maxPendingRuns+3; i++ {
+		if err := scheduler.TriggerNow("slow"); err != nil {
+			t.Fatalf("TriggerNow: %v", err)
+		}
+	}
+	waitSignal(t, started, "the first run to start")
+
+	stats := job.Stats()
+	if stats.Pending != maxPendingRuns || stats.Skipped != 2 {
+		t.Fatalf("stats = %+v, want %d pending and 2 skipped", stats, maxPendingRuns)
+	}
+	close(release)
+	waitRuns(t, job)
+}
@@ -0,0 +1,73 @@
+package cronjob
+
+import (
+	"context"
+	"fmt"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// PanicError is returned for a run whose job function panicked
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// WithMaxPanics disables the job after the given number of consecutive
+// panicking runs; 0 keeps the job scheduled regardless
+func WithMaxPanics(max int) JobOption {
+	return func(j *Job) {
+		j.maxPanics = max
+	}
+}
+
+// safeCall invokes the job function and turns a panic into a *PanicError
+func (j *Job) safeCall(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorWithStack("Job '%s' panicked: %v", j.Name, r)
+			err = &PanicError{Value: r}
+		}
+	}()
+
+	return j.Fn(ctx)
+}
+
+// trackPanics counts consecutive panics and disables the job once the
+// configured limit is reached
+func (j *Job) trackPanics(err error) {
+	_, panicked := err.(*PanicError)
+
+	j.mu.Lock()
+	if !panicked {
+		j.consecutivePanics = 0
+		j.mu.Unlock()
+		return
+	}
+
+	j.stats.Panics++
+	j.consecutivePanics++
+	disable := j.maxPanics > 0 && j.consecutivePanics >= j.maxPanics && !j.disabled
+	if disable {
+		j.disabled = true
+	}
+	j.mu.Unlock()
+
+	if disable {
+		logger.Error.Printf("Job '%s' panicked %d times in a row, disabling it", j.Name, j.maxPanics)
+		if err := j.Stop(); err != nil {
+			logger.Error.Printf("Failed to stop job '%s': %v", j.Name, err)
+		}
+	}
+}
+
+// IsDisabled returns whether the job was disabled after repeated panics;
+// starting the job again re-enables it
+func (j *Job) IsDisabled() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.disabled
+}
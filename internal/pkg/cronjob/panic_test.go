@@ -0,0 +1,78 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	events := make(chan Event, 16)
+	scheduler.SetEventHandler(func(event Event) {
+		events <- event
+	})
+
+	job, err := scheduler.AddJob("buggy", 10*time.Second, func(ctx context.Context) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	}, WithRunOnStart(false), WithMaxPanics(2), WithRetry(DefaultRetryPolicy))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	scheduler.StartAll()
+
+	waitArmed(t, clock, job, start.Add(10*time.Second))
+	clock.Advance(10 * time.Second)
+	waitFor(t, "the first panic", func() bool { return job.Stats().Panics == 1 })
+	waitRuns(t, job)
+
+	// A panic is recorded as a failure, not retried and the job stays
+	// scheduled until the limit is reached
+	if executions := job.Stats().Executions; executions != 1 {
+		t.Fatalf("executions = %d after a panic, want 1", executions)
+	}
+	if !job.IsRunning() || job.IsDisabled() {
+		t.Fatal("job stopped after a single panic")
+	}
+	<-events
+	if event := <-events; event.Type != EventFailed || event.Error == "" {
+		t.Fatalf("event = %+v, want a failure with the panic", event)
+	}
+
+	waitArmed(t, clock, job, start.Add(20*time.Second))
+	clock.Advance(10 * time.Second)
+	waitFor(t, "the job to be disabled", job.IsDisabled)
+	waitRuns(t, job)
+
+	if job.IsRunning() || job.Stats().Panics != 2 {
+		t.Fatalf("job running = %v with %d panics, want stopped after 2", job.IsRunning(), job.Stats().Panics)
+	}
+
+	// Starting the job again re-enables it
+	if err := scheduler.Start("buggy"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if job.IsDisabled() {
+		t.Fatal("job still disabled after Start")
+	}
+}
+
+func TestSafeCallReturnsPanicError(t *testing.T) {
+	job := newJob("buggy", Every(time.Minute), func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	err := job.safeCall(context.Background())
+	var panicked *PanicError
+	if !errors.As(err, &panicked) || panicked.Value != "boom" {
+		t.Fatalf("safeCall error = %v, want a *PanicError with the panic value", err)
+	}
+	if IsRetryable(err) {
+		t.Fatal("a panic is retryable")
+	}
+}
@@ -0,0 +1,35 @@
+package cronjob
+
+import (
+	"context"
+	"sync"
+)
+
+// runResult holds the summary a job function reports for its attempt
+type runResult struct {
+	mu      sync.Mutex
+	summary string
+}
+
+type runResultKey struct{}
+
+// SetResult reports a short summary of what the current attempt did, e.g.
+// how much it cleaned up. The summary is stored with the recorded run, sent
+// with the finishing event and shown in the job status. It does nothing
+// outside of a job run.
+func SetResult(ctx context.Context, summary string) {
+	result, ok := ctx.Value(runResultKey{}).(*runResult)
+	if !ok {
+		return
+	}
+
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	result.summary = summary
+}
+
+func (r *runResult) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.summary
+}
@@ -0,0 +1,67 @@
+package cronjob
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+)
+
+// memRecorder keeps recorded runs in memory
+type memRecorder struct {
+	mu   sync.Mutex
+	runs []models.JobRun
+}
+
+func (r *memRecorder) RecordRun(run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func TestSetResultIsRecorded(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, _ := newTestScheduler(t, start)
+
+	recorder := &memRecorder{}
+	scheduler.SetRecorder(recorder)
+	events := make(chan Event, 4)
+	scheduler.SetEventHandler(func(event Event) {
+		events <- event
+	})
+
+	job, err := scheduler.AddJob("cleanup", time.Hour, func(ctx context.Context) error {
+		SetResult(ctx, "removed 3 files")
+		return nil
+	}, WithRunOnStart(false))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+
+	if err := scheduler.TriggerNow("cleanup"); err != nil {
+		t.Fatalf("TriggerNow: %v", err)
+	}
+	waitFor(t, "the run to finish", func() bool { return job.Stats().Executions == 1 })
+	waitRuns(t, job)
+
+	if status := job.Status(); status.LastResult != "removed 3 files" {
+		t.Fatalf("last result = %q, want the reported summary", status.LastResult)
+	}
+	<-events
+	if event := <-events; event.Type != EventSucceeded || event.Result != "removed 3 files" {
+		t.Fatalf("event = %+v, want a success with the summary", event)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.runs) != 1 || recorder.runs[0].Result != "removed 3 files" {
+		t.Fatalf("recorded runs = %+v, want one with the summary", recorder.runs)
+	}
+}
+
+func TestSetResultOutsideRun(t *testing.T) {
+	// Must not panic when the function is called directly
+	SetResult(context.Background(), "ignored")
+}
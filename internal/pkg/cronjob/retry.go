@@ -0,0 +1,75 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+
+	"onx-screen-record/internal/pkg/backoff"
+)
+
+// RetryPolicy controls how a failed run is retried before waiting for the
+// next scheduled activation
+type RetryPolicy struct {
+	MaxAttempts int            // total attempts per activation, including the first
+	Backoff     backoff.Policy // delay between attempts
+	Retryable   func(error) bool
+}
+
+// DefaultRetryPolicy retries transient failures four times with backoff.Default,
+// waiting about 15 seconds in total
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     backoff.Default,
+}
+
+// WithRetry retries failed runs according to policy
+func WithRetry(policy RetryPolicy) JobOption {
+	return func(j *Job) {
+		j.retry = &policy
+	}
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so the job is not retried until its next activation
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable is the default classification: everything except permanent
+// errors, panics and cancellation is retried
+func IsRetryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var panicked *PanicError
+	if errors.As(err, &panicked) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
@@ -0,0 +1,79 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/pkg/backoff"
+)
+
+func TestRetryBackoffTiming(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	attempts := make(chan time.Time, 16)
+	job, err := scheduler.AddJob("flaky", time.Hour, func(ctx context.Context) error {
+		attempts <- clock.Now()
+		return errors.New("service unavailable")
+	}, WithRunOnStart(false), WithRetry(RetryPolicy{
+		MaxAttempts: 4,
+		Backoff:     backoff.Policy{Initial: 10 * time.Second, Max: 30 * time.Second, Multiplier: 2},
+	}))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+
+	if err := scheduler.TriggerNow("flaky"); err != nil {
+		t.Fatalf("TriggerNow: %v", err)
+	}
+	expectRun(t, attempts, start)
+
+	// Delays of 10s, 20s and 30s, capped by Max
+	at := start
+	for _, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		clock.BlockUntil(1)
+		clock.Advance(delay - time.Second)
+		expectNoRun(t, attempts)
+
+		clock.Advance(time.Second)
+		at = at.Add(delay)
+		expectRun(t, attempts, at)
+	}
+
+	waitRuns(t, job)
+	if waiters := clock.Waiters(); waiters != 0 {
+		t.Fatalf("%d timers pending after the last attempt, want none", waiters)
+	}
+	if status, _ := scheduler.GetJob("flaky"); status.FailureCount != 4 || status.RunCount != 4 {
+		t.Fatalf("run count %d with %d failures, want 4 and 4", status.RunCount, status.FailureCount)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	scheduler, clock := newTestScheduler(t, start)
+
+	attempts := make(chan time.Time, 16)
+	job, err := scheduler.AddJob("invalid", time.Hour, func(ctx context.Context) error {
+		attempts <- clock.Now()
+		return Permanent(errors.New("invalid configuration"))
+	}, WithRunOnStart(false), WithRetry(DefaultRetryPolicy))
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+
+	if err := scheduler.TriggerNow("invalid"); err != nil {
+		t.Fatalf("TriggerNow: %v", err)
+	}
+	expectRun(t, attempts, start)
+	waitRuns(t, job)
+
+	if waiters := clock.Waiters(); waiters != 0 {
+		t.Fatalf("%d timers pending after a permanent error, want none", waiters)
+	}
+	if executions := job.Stats().Executions; executions != 1 {
+		t.Fatalf("executions = %d, want 1", executions)
+	}
+}
@@ -0,0 +1,361 @@
+package cronjob
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a job runs next
+type Schedule interface {
+	// Next returns the first activation time strictly after t, or the zero
+	// time when the schedule never fires again
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// IntervalSchedule fires at a fixed interval
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+// Every returns a schedule firing every interval
+func Every(interval time.Duration) *IntervalSchedule {
+	return &IntervalSchedule{Interval: interval}
+}
+
+// Next returns t plus the interval; a schedule without a positive interval
+// never fires
+func (s *IntervalSchedule) Next(t time.Time) time.Time {
+	if s.Interval <= 0 {
+		return time.Time{}
+	}
+	return t.Add(s.Interval)
+}
+
+func (s *IntervalSchedule) String() string {
+	return "@every " + s.Interval.String()
+}
+
+// validateSchedule rejects interval schedules without a positive interval,
+// which would otherwise fire in a loop
+func validateSchedule(schedule Schedule) error {
+	if interval, ok := schedule.(*IntervalSchedule); ok && interval.Interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %v", interval.Interval)
+	}
+	return nil
+}
+
+// CronSchedule fires on the times matched by a cron expression
+type CronSchedule struct {
+	expr     string
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool
+	dowStar  bool
+	location *time.Location
+}
+
+type cronField struct {
+	name   string
+	min    int
+	max    int
+	names  map[string]int
+	target *uint64
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron parses a cron expression evaluated in the local time zone
+func ParseCron(expr string) (Schedule, error) {
+	return ParseCronInLocation(expr, time.Local)
+}
+
+// ParseCronInLocation parses a cron expression evaluated in loc.
+//
+// Supported forms:
+//   - 5 fields: minute hour day-of-month month day-of-week
+//   - 6 fields: second minute hour day-of-month month day-of-week
+//   - @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//   - @every <duration>, e.g. "@every 1h30m"
+//
+// Fields accept *, ?, lists (1,15), ranges (1-5), steps (*/10, 0-30/5) and
+// month/day names (JAN, MON). A "CRON_TZ=Asia/Jakarta " or "TZ=..." prefix
+// overrides loc.
+func ParseCronInLocation(expr string, loc *time.Location) (Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if spec == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		zone, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		loc = zone
+		spec = strings.TrimSpace(rest)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("@every duration must be positive")
+		}
+		return Every(interval), nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %q", spec)
+		}
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron expression %q must have 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	schedule := &CronSchedule{expr: strings.TrimSpace(expr), location: loc}
+	specs := []cronField{
+		{name: "second", min: 0, max: 59, target: &schedule.second},
+		{name: "minute", min: 0, max: 59, target: &schedule.minute},
+		{name: "hour", min: 0, max: 23, target: &schedule.hour},
+		{name: "day of month", min: 1, max: 31, target: &schedule.dom},
+		{name: "month", min: 1, max: 12, names: monthNames, target: &schedule.month},
+		{name: "day of week", min: 0, max: 7, names: dayNames, target: &schedule.dow},
+	}
+
+	for i, field := range specs {
+		bits, err := parseCronField(fields[i], field)
+		if err != nil {
+			return nil, err
+		}
+		*field.target = bits
+	}
+
+	// 7 is an alias for Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow = schedule.dow&^(1<<7) | 1
+	}
+	schedule.domStar = isWildcard(fields[3])
+	schedule.dowStar = isWildcard(fields[5])
+
+	return schedule, nil
+}
+
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case isWildcard(rangeExpr):
+		case strings.Contains(rangeExpr, "-"):
+			low, high, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if start, err = parseCronValue(low, field); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(high, field); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseCronValue(rangeExpr, field)
+			if err != nil {
+				return 0, err
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, field.name)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	if field.names != nil {
+		if named, ok := field.names[strings.ToLower(value)]; ok {
+			return named, nil
+		}
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, field.name)
+	}
+	if number < field.min || number > field.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d] in %s field", number, field.min, field.max, field.name)
+	}
+	return number, nil
+}
+
+// Next returns the first time after t matching the expression. Times in
+// the hour skipped when daylight saving starts never match; times in the
+// hour repeated when it ends match in both occurrences.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	origLocation := t.Location()
+	t = t.In(s.location)
+
+	// Start at the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 0, 1)
+
+		// Stay on midnight when crossing a daylight saving change
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+			}
+		}
+
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	// Within a day the time is truncated by subtracting, as time.Date would
+	// move a time in a repeated hour back to its first occurrence
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Add(-time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = t.Add(-time.Duration(t.Nanosecond()))
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(origLocation)
+}
+
+// dayMatches applies the cron rule that day-of-month and day-of-week are
+// OR-ed when both are restricted and AND-ed otherwise
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Location returns the time zone the expression is evaluated in
+func (s *CronSchedule) Location() *time.Location {
+	return s.location
+}
+
+func (s *CronSchedule) String() string {
+	return s.expr
+}
@@ -0,0 +1,229 @@
+package cronjob
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+// nextTimes returns the next n activations after from, formatted as RFC 3339
+func nextTimes(schedule Schedule, from time.Time, n int) []string {
+	var times []string
+	t := from
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+		if t.IsZero() {
+			return append(times, "never")
+		}
+		times = append(times, t.Format(time.RFC3339))
+	}
+	return times
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// A Monday
+	from := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{name: "5 fields", expr: "*/15 * * * *", want: []string{"2026-01-05T10:15:00Z", "2026-01-05T10:30:00Z", "2026-01-05T10:45:00Z"}},
+		{name: "6 fields", expr: "30 */20 * * * *", want: []string{"2026-01-05T10:00:30Z", "2026-01-05T10:20:30Z", "2026-01-05T10:40:30Z"}},
+		{name: "range with step", expr: "0 9-17/4 * * *", want: []string{"2026-01-05T13:00:00Z", "2026-01-05T17:00:00Z", "2026-01-06T09:00:00Z"}},
+		{name: "value with step", expr: "0 20/2 * * *", want: []string{"2026-01-05T20:00:00Z", "2026-01-05T22:00:00Z", "2026-01-06T20:00:00Z"}},
+		{name: "list", expr: "0 0 1,15 * *", want: []string{"2026-01-15T00:00:00Z", "2026-02-01T00:00:00Z", "2026-02-15T00:00:00Z"}},
+		{name: "list of ranges", expr: "0 8-9,18 * * *", want: []string{"2026-01-05T18:00:00Z", "2026-01-06T08:00:00Z", "2026-01-06T09:00:00Z"}},
+		{name: "month and day names", expr: "0 12 * JAN-MAR MON", want: []string{"2026-01-05T12:00:00Z", "2026-01-12T12:00:00Z", "2026-01-19T12:00:00Z"}},
+		{name: "lowercase names", expr: "0 8 * * mon-fri", want: []string{"2026-01-06T08:00:00Z", "2026-01-07T08:00:00Z", "2026-01-08T08:00:00Z"}},
+		{name: "7 is sunday", expr: "0 0 * * 7", want: []string{"2026-01-11T00:00:00Z", "2026-01-18T00:00:00Z"}},
+		{name: "0 is sunday", expr: "0 0 * * SUN", want: []string{"2026-01-11T00:00:00Z", "2026-01-18T00:00:00Z"}},
+		{name: "month with day of month", expr: "0 0 10 mar,jun *", want: []string{"2026-03-10T00:00:00Z", "2026-06-10T00:00:00Z", "2027-03-10T00:00:00Z"}},
+		{name: "leap day", expr: "0 0 29 2 *", want: []string{"2028-02-29T00:00:00Z", "2032-02-29T00:00:00Z"}},
+		{name: "never within 5 years", expr: "0 0 30 2 *", want: []string{"never"}},
+
+		// Restricted day of month and day of week match either
+		{name: "day of month or day of week", expr: "0 0 13 * FRI", want: []string{"2026-01-09T00:00:00Z", "2026-01-13T00:00:00Z", "2026-01-16T00:00:00Z"}},
+		// A wildcard day field leaves only the other one
+		{name: "day of month only", expr: "0 0 13 * *", want: []string{"2026-01-13T00:00:00Z", "2026-02-13T00:00:00Z"}},
+		{name: "day of week only", expr: "0 0 * * FRI", want: []string{"2026-01-09T00:00:00Z", "2026-01-16T00:00:00Z"}},
+		{name: "question mark wildcard", expr: "0 0 ? * FRI", want: []string{"2026-01-09T00:00:00Z", "2026-01-16T00:00:00Z"}},
+
+		{name: "@yearly", expr: "@yearly", want: []string{"2027-01-01T00:00:00Z", "2028-01-01T00:00:00Z"}},
+		{name: "@annually", expr: "@annually", want: []string{"2027-01-01T00:00:00Z"}},
+		{name: "@monthly", expr: "@monthly", want: []string{"2026-02-01T00:00:00Z", "2026-03-01T00:00:00Z"}},
+		{name: "@weekly", expr: "@weekly", want: []string{"2026-01-11T00:00:00Z", "2026-01-18T00:00:00Z"}},
+		{name: "@daily", expr: "@daily", want: []string{"2026-01-06T00:00:00Z", "2026-01-07T00:00:00Z"}},
+		{name: "@midnight", expr: "@midnight", want: []string{"2026-01-06T00:00:00Z"}},
+		{name: "@hourly", expr: "@hourly", want: []string{"2026-01-05T11:00:00Z", "2026-01-05T12:00:00Z"}},
+		{name: "descriptor case", expr: "@DAILY", want: []string{"2026-01-06T00:00:00Z"}},
+		{name: "@every", expr: "@every 1h30m", want: []string{"2026-01-05T11:30:00Z", "2026-01-05T13:00:00Z"}},
+
+		{name: "CRON_TZ prefix", expr: "CRON_TZ=Asia/Jakarta 0 9 * * *", want: []string{"2026-01-06T02:00:00Z", "2026-01-07T02:00:00Z"}},
+		{name: "TZ prefix", expr: "TZ=America/New_York 0 9 * * *", want: []string{"2026-01-05T14:00:00Z", "2026-01-06T14:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronInLocation(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseCronInLocation(%q): %v", tt.expr, err)
+			}
+
+			got := nextTimes(schedule, from, len(tt.want))
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("%q from %v = %v, want %v", tt.expr, from.Format(time.RFC3339), got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronScheduleDaylightSaving(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []string
+	}{
+		// 2026-03-08 02:00 EST jumps to 03:00 EDT
+		{
+			name: "skipped hour does not fire",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []string{"2026-03-09T02:30:00-04:00", "2026-03-10T02:30:00-04:00"},
+		},
+		{
+			name: "steps continue after the skipped hour",
+			expr: "*/30 * * * *",
+			from: time.Date(2026, 3, 8, 1, 15, 0, 0, newYork),
+			want: []string{"2026-03-08T01:30:00-05:00", "2026-03-08T03:00:00-04:00", "2026-03-08T03:30:00-04:00"},
+		},
+		{
+			name: "midnight across the skipped hour",
+			expr: "@daily",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []string{"2026-03-08T00:00:00-05:00", "2026-03-09T00:00:00-04:00"},
+		},
+		// 2026-11-01 02:00 EDT falls back to 01:00 EST
+		{
+			name: "repeated hour fires in both occurrences",
+			expr: "30 1 * * *",
+			from: time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want: []string{"2026-11-01T01:30:00-04:00", "2026-11-01T01:30:00-05:00", "2026-11-02T01:30:00-05:00"},
+		},
+		{
+			name: "hourly through the repeated hour",
+			expr: "@hourly",
+			from: time.Date(2026, 11, 1, 0, 30, 0, 0, newYork),
+			want: []string{"2026-11-01T01:00:00-04:00", "2026-11-01T01:00:00-05:00", "2026-11-01T02:00:00-05:00"},
+		},
+		{
+			name: "steps through the repeated hour",
+			expr: "0 */30 * * * *",
+			from: time.Date(2026, 11, 1, 0, 45, 0, 0, newYork),
+			want: []string{
+				"2026-11-01T01:00:00-04:00", "2026-11-01T01:30:00-04:00",
+				"2026-11-01T01:00:00-05:00", "2026-11-01T01:30:00-05:00",
+				"2026-11-01T02:00:00-05:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronInLocation(tt.expr, newYork)
+			if err != nil {
+				t.Fatalf("ParseCronInLocation(%q): %v", tt.expr, err)
+			}
+
+			got := nextTimes(schedule, tt.from, len(tt.want))
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("%q from %v = %v, want %v", tt.expr, tt.from.Format(time.RFC3339), got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronScheduleNextMovesForward(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	exprs := []string{"*/7 * * * *", "30 1 * * *", "0 */30 * * * *", "*/20 * 1-2 * * *", "@hourly"}
+
+	for _, expr := range exprs {
+		schedule, err := ParseCronInLocation(expr, newYork)
+		if err != nil {
+			t.Fatalf("ParseCronInLocation(%q): %v", expr, err)
+		}
+
+		// Both daylight saving changes of 2026
+		for _, from := range []time.Time{
+			time.Date(2026, 3, 7, 0, 0, 0, 0, newYork),
+			time.Date(2026, 10, 31, 0, 0, 0, 0, newYork),
+		} {
+			prev := from
+			for prev.Before(from.Add(72 * time.Hour)) {
+				next := schedule.Next(prev)
+				if !next.After(prev) {
+					t.Fatalf("%q: Next(%v) = %v, want a later time", expr, prev.Format(time.RFC3339), next.Format(time.RFC3339))
+				}
+				prev = next
+			}
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"60 * * * * *",
+		"-1 * * * *",
+		"5-1 * * * *",
+		"* * * DEC-JAN *",
+		"*/0 * * * *",
+		"1-5/0 * * * *",
+		"*/-2 * * * *",
+		"*/x * * * *",
+		"foo * * * *",
+		"* * * FOO *",
+		"* * * * MONDAY",
+		"@fortnightly",
+		"@every 0s",
+		"@every -1m",
+		"@every soon",
+		"CRON_TZ=Mars/Olympus 0 9 * * *",
+		"TZ=Nowhere 0 9 * * *",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if schedule, err := ParseCronInLocation(expr, time.UTC); err == nil {
+				t.Fatalf("ParseCronInLocation(%q) = %v, want an error", expr, schedule)
+			}
+		})
+	}
+}
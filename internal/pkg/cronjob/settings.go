@@ -0,0 +1,214 @@
+package cronjob
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// SettingsSource provides the effective value of a setting, empty when unset
+type SettingsSource interface {
+	Get(key string) string
+}
+
+// jobSettings binds a job to the settings controlling it; the applied
+// values are remembered so a job is only changed when its settings change
+type jobSettings struct {
+	scheduleKey     string
+	enabledKey      string
+	appliedSchedule string
+	appliedEnabled  string
+}
+
+// WithSettings binds the schedule and the enabled flag of the job to
+// settings, see Scheduler.ApplySettings. Either key may be empty.
+func WithSettings(scheduleKey, enabledKey string) JobOption {
+	return func(j *Job) {
+		j.settings = &jobSettings{
+			scheduleKey: scheduleKey,
+			enabledKey:  enabledKey,
+		}
+	}
+}
+
+// ParseSchedule parses a schedule setting, either a duration such as "15m"
+// for an interval or a cron expression accepted by ParseCron
+func ParseSchedule(spec string) (Schedule, error) {
+	return parseSchedule(spec, time.Local)
+}
+
+func parseSchedule(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval <= 0 {
+			return nil, fmt.Errorf("schedule interval must be positive, got %v", interval)
+		}
+		return Every(interval), nil
+	}
+	return ParseCronInLocation(spec, loc)
+}
+
+// ValidateSetting checks a value for a setting bound to a job, so invalid
+// schedules can be rejected before they are saved. Other keys and empty
+// values are always valid.
+func (s *Scheduler) ValidateSetting(key, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.jobs {
+		if job.settings == nil {
+			continue
+		}
+		switch key {
+		case job.settings.scheduleKey:
+			if _, err := parseSchedule(value, job.location); err != nil {
+				return fmt.Errorf("invalid schedule for job '%s': %w", job.Name, err)
+			}
+		case job.settings.enabledKey:
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("invalid enabled flag for job '%s': %q is not a bool", job.Name, value)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplySettings reschedules, pauses or resumes every job bound to settings.
+// An unset schedule restores the schedule the job was added with and an
+// unset enabled flag means enabled. Invalid values leave the job unchanged
+// and are returned.
+func (s *Scheduler) ApplySettings(settings SettingsSource) error {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	s.mu.RLock()
+	jobs := append([]*Job(nil), s.jobs...)
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, job := range jobs {
+		if job.settings == nil {
+			continue
+		}
+		if err := s.applyJobSettings(job, settings); err != nil {
+			logger.Error.Printf("Failed to apply settings of job '%s': %v", job.Name, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) applyJobSettings(job *Job, settings SettingsSource) error {
+	var errs []error
+
+	if key := job.settings.scheduleKey; key != "" {
+		if spec := settings.Get(key); spec != job.settings.appliedSchedule {
+			schedule := job.defaultSchedule
+			var err error
+			if spec != "" {
+				schedule, err = parseSchedule(spec, job.location)
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid schedule %q in setting '%s': %w", spec, key, err))
+			} else {
+				job.settings.appliedSchedule = spec
+				if schedule.String() != job.GetSchedule().String() {
+					job.setSchedule(schedule)
+					logger.Info.Printf("Cron job '%s' rescheduled from setting '%s' to: %s", job.Name, key, schedule)
+				}
+			}
+		}
+	}
+
+	if key := job.settings.enabledKey; key != "" {
+		if value := settings.Get(key); value != job.settings.appliedEnabled {
+			enabled := true
+			var err error
+			if value != "" {
+				enabled, err = strconv.ParseBool(value)
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid enabled flag %q in setting '%s'", value, key))
+			} else {
+				job.settings.appliedEnabled = value
+				if enabled {
+					err = s.Resume(job.Name)
+				} else {
+					err = s.Pause(job.Name)
+				}
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Pause stops a job until it is resumed; unlike Stop, StartAll leaves a
+// paused job alone
+func (s *Scheduler) Pause(jobName string) error {
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+
+	job.mu.Lock()
+	if job.paused {
+		job.mu.Unlock()
+		return nil
+	}
+	job.paused = true
+	job.mu.Unlock()
+
+	logger.Info.Printf("Cron job '%s' paused", jobName)
+	if job.IsRunning() {
+		return job.Stop()
+	}
+	return nil
+}
+
+// Resume lifts a pause, starting the job when the scheduler is started
+func (s *Scheduler) Resume(jobName string) error {
+	job, err := s.find(jobName)
+	if err != nil {
+		return err
+	}
+
+	job.mu.Lock()
+	if !job.paused {
+		job.mu.Unlock()
+		return nil
+	}
+	job.paused = false
+	job.mu.Unlock()
+
+	logger.Info.Printf("Cron job '%s' resumed", jobName)
+
+	s.mu.RLock()
+	started := s.started && !s.closed
+	s.mu.RUnlock()
+
+	if started && !job.IsRunning() {
+		return job.Start(s.ctx)
+	}
+	return nil
+}
+
+// IsPaused returns whether the job is paused
+func (j *Job) IsPaused() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.paused
+}
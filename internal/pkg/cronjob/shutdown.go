@@ -0,0 +1,85 @@
+package cronjob
+
+import (
+	"context"
+	"sync"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// ShutdownReport lists how the in-flight runs ended during shutdown
+type ShutdownReport struct {
+	Completed []string `json:"completed"`
+	Abandoned []string `json:"abandoned"`
+}
+
+// Shutdown stops every job, cancels the context of in-flight runs and waits
+// until their job functions returned or ctx is done. Jobs still running at that point are reported
+// as abandoned and ctx.Err() is returned. The scheduler cannot be restarted.
+func (s *Scheduler) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return &ShutdownReport{}, ErrSchedulerClosed
+	}
+	s.closed = true
+	jobs := append([]*Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	logger.Info.Printf("Shutting down scheduler with %d jobs", len(jobs))
+
+	s.StopAll()
+	s.cancel()
+
+	report := &ShutdownReport{
+		Completed: make([]string, 0),
+		Abandoned: make([]string, 0),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		// dispatch checks the cancelled context under the job lock, so the
+		// in-flight count cannot grow anymore
+		if job.Stats().InFlight == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+
+			done := make(chan struct{})
+			go func() {
+				job.runs.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				mu.Lock()
+				report.Completed = append(report.Completed, job.Name)
+				mu.Unlock()
+			case <-ctx.Done():
+				mu.Lock()
+				report.Abandoned = append(report.Abandoned, job.Name)
+				mu.Unlock()
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	if len(report.Abandoned) > 0 {
+		logger.Warning.Printf("Scheduler shut down, abandoned in-flight jobs: %v", report.Abandoned)
+		return report, ctx.Err()
+	}
+
+	logger.Info.Println("Scheduler shut down cleanly")
+	return report, nil
+}
+
+func (s *Scheduler) isClosed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.closed
+}
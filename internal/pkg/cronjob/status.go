@@ -0,0 +1,124 @@
+package cronjob
+
+import (
+	"time"
+)
+
+// JobState is the lifecycle state of a job
+type JobState string
+
+const (
+	JobStateStopped   JobState = "stopped"
+	JobStateScheduled JobState = "scheduled"
+	JobStateRunning   JobState = "running"
+	JobStateDisabled  JobState = "disabled"
+	JobStatePaused    JobState = "paused"
+)
+
+// JobStatus is a snapshot of a job for display
+type JobStatus struct {
+	Name         string          `json:"name"`
+	Schedule     string          `json:"schedule"`
+	State        JobState        `json:"state"`
+	NextRun      *time.Time      `json:"nextRun,omitempty"`
+	LastRun      *time.Time      `json:"lastRun,omitempty"`
+	LastDuration time.Duration   `json:"lastDurationNs"`
+	LastError    string          `json:"lastError,omitempty"`
+	LastResult   string          `json:"lastResult,omitempty"`
+	RunCount     int64           `json:"runCount"`
+	FailureCount int64           `json:"failureCount"`
+	Stats        JobStats        `json:"stats"`
+	Workflow     *WorkflowStatus `json:"workflow,omitempty"`
+}
+
+// EventType identifies a job lifecycle event
+type EventType string
+
+const (
+	EventStarted   EventType = "started"
+	EventSucceeded EventType = "succeeded"
+	EventFailed    EventType = "failed"
+)
+
+// Event is emitted when a job attempt starts and when it finishes
+type Event struct {
+	Type      EventType     `json:"type"`
+	Job       string        `json:"job"`
+	Attempt   int           `json:"attempt"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"durationNs,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Result    string        `json:"result,omitempty"`
+}
+
+// EventHandler receives job lifecycle events; it is called synchronously
+// from the job goroutine and must not block
+type EventHandler func(Event)
+
+// SetEventHandler sets the handler receiving the events of every job
+func (s *Scheduler) SetEventHandler(handler EventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks.onEvent = handler
+	for _, job := range s.jobs {
+		job.setHooks(s.hooks)
+	}
+}
+
+// Status returns a snapshot of the job
+func (j *Job) Status() JobStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	status := JobStatus{
+		Name:         j.Name,
+		State:        JobStateStopped,
+		LastDuration: j.lastDuration,
+		LastError:    j.lastError,
+		LastResult:   j.lastResult,
+		RunCount:     j.runCount,
+		FailureCount: j.failureCount,
+		Stats:        j.stats,
+	}
+	if j.Schedule != nil {
+		status.Schedule = j.Schedule.String()
+	}
+
+	switch {
+	case j.disabled:
+		status.State = JobStateDisabled
+	case j.paused && j.stats.InFlight == 0:
+		status.State = JobStatePaused
+	case j.stats.InFlight > 0:
+		status.State = JobStateRunning
+	case j.running:
+		status.State = JobStateScheduled
+	}
+
+	if j.running && !j.nextRunAt.IsZero() {
+		nextRun := j.nextRunAt
+		status.NextRun = &nextRun
+	}
+	if !j.lastRun.IsZero() {
+		lastRun := j.lastRun
+		status.LastRun = &lastRun
+	}
+	if j.workflow != nil {
+		workflow := j.workflow.Status()
+		status.Workflow = &workflow
+	}
+
+	return status
+}
+
+// emit sends an event to the handler, if any
+func (j *Job) emit(event Event) {
+	j.mu.RLock()
+	handler := j.hooks.onEvent
+	j.mu.RUnlock()
+
+	if handler != nil {
+		handler(event)
+	}
+}
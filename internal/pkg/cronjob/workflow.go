@@ -0,0 +1,314 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// StepCondition decides whether a workflow step runs, based on the outcome
+// of the steps before it
+type StepCondition int
+
+const (
+	// RunOnSuccess runs the step when every previous step succeeded
+	RunOnSuccess StepCondition = iota
+	// RunOnFailure runs the step when a previous step failed
+	RunOnFailure
+	// RunAlways runs the step regardless of previous outcomes
+	RunAlways
+)
+
+func (c StepCondition) String() string {
+	switch c {
+	case RunOnSuccess:
+		return "on_success"
+	case RunOnFailure:
+		return "on_failure"
+	case RunAlways:
+		return "always"
+	default:
+		return ""
+	}
+}
+
+// StepState is the state of a workflow step or of the whole workflow
+type StepState string
+
+const (
+	StepPending   StepState = "pending"
+	StepRunning   StepState = "running"
+	StepSucceeded StepState = "succeeded"
+	StepFailed    StepState = "failed"
+	StepSkipped   StepState = "skipped"
+)
+
+// finallyStepTimeout bounds a RunAlways step that runs after the workflow
+// context was cancelled
+const finallyStepTimeout = 30 * time.Second
+
+// Step is a single unit of work in a workflow
+type Step struct {
+	Name      string
+	Fn        JobFunc
+	Condition StepCondition
+}
+
+// StepStatus reports the outcome of a step in the latest workflow run
+type StepStatus struct {
+	Name       string     `json:"name"`
+	Condition  string     `json:"condition"`
+	State      StepState  `json:"state"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// WorkflowStatus reports the latest run of a workflow
+type WorkflowStatus struct {
+	Name       string       `json:"name"`
+	State      StepState    `json:"state"`
+	StartedAt  *time.Time   `json:"startedAt,omitempty"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	Steps      []StepStatus `json:"steps"`
+}
+
+// Workflow chains steps such as "backup then cleanup" or "transcode then
+// upload" into a single job. Steps share a RunState for passing results.
+type Workflow struct {
+	Name   string
+	steps  []Step
+	status WorkflowStatus
+	clock  Clock
+	mu     sync.RWMutex
+}
+
+// NewWorkflow creates an empty workflow
+func NewWorkflow(name string) *Workflow {
+	return &Workflow{
+		Name:  name,
+		steps: make([]Step, 0),
+		clock: RealClock,
+	}
+}
+
+// Then adds a step that runs when every previous step succeeded
+func (w *Workflow) Then(name string, fn JobFunc) *Workflow {
+	return w.AddStep(Step{Name: name, Fn: fn, Condition: RunOnSuccess})
+}
+
+// OnFailure adds a step that runs when a previous step failed
+func (w *Workflow) OnFailure(name string, fn JobFunc) *Workflow {
+	return w.AddStep(Step{Name: name, Fn: fn, Condition: RunOnFailure})
+}
+
+// Finally adds a step that always runs, also after the workflow was
+// cancelled, in which case it gets a fresh context bounded by
+// finallyStepTimeout
+func (w *Workflow) Finally(name string, fn JobFunc) *Workflow {
+	return w.AddStep(Step{Name: name, Fn: fn, Condition: RunAlways})
+}
+
+// AddStep appends a step to the workflow
+func (w *Workflow) AddStep(step Step) *Workflow {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.steps = append(w.steps, step)
+	return w
+}
+
+// Run executes the steps in order and returns the errors of failed steps.
+// It has the JobFunc signature so a workflow can be scheduled as a job.
+func (w *Workflow) Run(ctx context.Context) error {
+	w.mu.Lock()
+	steps := append([]Step(nil), w.steps...)
+	clock := w.clock
+	startedAt := clock.Now()
+	w.status = WorkflowStatus{
+		Name:      w.Name,
+		State:     StepRunning,
+		StartedAt: &startedAt,
+		Steps:     make([]StepStatus, len(steps)),
+	}
+	for i, step := range steps {
+		w.status.Steps[i] = StepStatus{
+			Name:      step.Name,
+			Condition: step.Condition.String(),
+			State:     StepPending,
+		}
+	}
+	w.mu.Unlock()
+
+	ctx = context.WithValue(ctx, runStateKey{}, &RunState{values: make(map[string]interface{})})
+
+	var errs []error
+	for i, step := range steps {
+		failed := len(errs) > 0
+		cancelled := ctx.Err() != nil
+		if !step.shouldRun(failed) || (cancelled && step.Condition != RunAlways) {
+			w.updateStep(i, func(status *StepStatus) {
+				status.State = StepSkipped
+			})
+			continue
+		}
+
+		stepCtx := ctx
+		cancel := context.CancelFunc(func() {})
+		if cancelled {
+			stepCtx, cancel = withTimeout(clock, context.WithoutCancel(ctx), finallyStepTimeout)
+		}
+
+		stepStart := clock.Now()
+		w.updateStep(i, func(status *StepStatus) {
+			status.State = StepRunning
+			status.StartedAt = &stepStart
+		})
+
+		err := w.callStep(stepCtx, step)
+		cancel()
+
+		stepEnd := clock.Now()
+		w.updateStep(i, func(status *StepStatus) {
+			status.FinishedAt = &stepEnd
+			status.State = StepSucceeded
+			if err != nil {
+				status.State = StepFailed
+				status.Error = err.Error()
+			}
+		})
+
+		if err != nil {
+			logger.Error.Printf("Workflow '%s' step '%s' failed: %v", w.Name, step.Name, err)
+			errs = append(errs, fmt.Errorf("step '%s': %w", step.Name, err))
+		}
+	}
+
+	if err := ctx.Err(); err != nil && len(errs) == 0 {
+		errs = append(errs, err)
+	}
+
+	finishedAt := clock.Now()
+	w.mu.Lock()
+	w.status.FinishedAt = &finishedAt
+	w.status.State = StepSucceeded
+	if len(errs) > 0 {
+		w.status.State = StepFailed
+	}
+	w.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Status returns the status of the latest run
+func (w *Workflow) Status() WorkflowStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	status := w.status
+	status.Steps = append([]StepStatus(nil), w.status.Steps...)
+	if status.Name == "" {
+		status.Name = w.Name
+		status.State = StepPending
+		for _, step := range w.steps {
+			status.Steps = append(status.Steps, StepStatus{
+				Name:      step.Name,
+				Condition: step.Condition.String(),
+				State:     StepPending,
+			})
+		}
+	}
+	return status
+}
+
+func (w *Workflow) setClock(clock Clock) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clock = clock
+}
+
+func (w *Workflow) updateStep(index int, update func(*StepStatus)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	update(&w.status.Steps[index])
+}
+
+// callStep runs a step, turning a panic into a *PanicError so the
+// remaining steps and the status stay consistent
+func (w *Workflow) callStep(ctx context.Context, step Step) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorWithStack("Workflow '%s' step '%s' panicked: %v", w.Name, step.Name, r)
+			err = &PanicError{Value: r}
+		}
+	}()
+
+	return step.Fn(ctx)
+}
+
+func (s Step) shouldRun(failed bool) bool {
+	switch s.Condition {
+	case RunOnFailure:
+		return failed
+	case RunAlways:
+		return true
+	default:
+		return !failed
+	}
+}
+
+// RunState shares values between the steps of a single workflow run
+type RunState struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+type runStateKey struct{}
+
+// StateFromContext returns the shared state of the workflow run ctx belongs
+// to, or nil outside of a workflow
+func StateFromContext(ctx context.Context) *RunState {
+	state, _ := ctx.Value(runStateKey{}).(*RunState)
+	return state
+}
+
+// Set stores a value for the following steps
+func (r *RunState) Set(key string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = value
+}
+
+// Get returns a value stored by a previous step
+func (r *RunState) Get(key string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.values[key]
+	return value, ok
+}
+
+// AddWorkflow schedules a workflow as a job named after it
+func (s *Scheduler) AddWorkflow(workflow *Workflow, schedule Schedule, opts ...JobOption) (*Job, error) {
+	opts = append(opts, func(j *Job) {
+		j.workflow = workflow
+	})
+	return s.AddScheduledJob(workflow.Name, schedule, workflow.Run, opts...)
+}
+
+// WorkflowStatus returns the status of the latest run of a scheduled workflow
+func (s *Scheduler) WorkflowStatus(name string) (*WorkflowStatus, error) {
+	job, err := s.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if job.workflow == nil {
+		return nil, fmt.Errorf("job '%s' is not a workflow", name)
+	}
+
+	status := job.workflow.Status()
+	return &status, nil
+}
@@ -49,7 +49,8 @@ func initDB(ph *pathHelper.PathHelper) (*gorm.DB, error) {
 
 		dbPath := filepath.Join(appDataDir, "onx-screen-record.db")
 
-		db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		// Wait for locks instead of failing when another app instance writes
+		db, err := gorm.Open(sqlite.Open(dbPath+"?_busy_timeout=5000"), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Silent),
 			// Logger: logger.Default.LogMode(logger.Info),
 		})
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// autoVacuumIncremental is the incremental mode of PRAGMA auto_vacuum
+const autoVacuumIncremental = 2
+
+// VacuumReport describes the outcome of Vacuum
+type VacuumReport struct {
+	PageSize       int64 `json:"page_size"`
+	PageCount      int64 `json:"page_count"`
+	FreePages      int64 `json:"free_pages"`
+	ReclaimedPages int64 `json:"reclaimed_pages"`
+	FullVacuum     bool  `json:"full_vacuum"`
+}
+
+// ReclaimedBytes returns the space given back to the file system
+func (r *VacuumReport) ReclaimedBytes() int64 {
+	return r.ReclaimedPages * r.PageSize
+}
+
+// Optimize refreshes the query planner statistics
+func (d *Database) Optimize(ctx context.Context) error {
+	db := d.DB.WithContext(ctx)
+
+	if err := db.Exec("PRAGMA optimize").Error; err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+	if err := db.Exec("ANALYZE").Error; err != nil {
+		return fmt.Errorf("failed to analyze database: %w", err)
+	}
+	return nil
+}
+
+// Vacuum returns free pages to the file system once they exceed
+// minFreeRatio of the database. The first run switches the database to
+// incremental auto-vacuum, which needs a one-off full VACUUM. Everything
+// runs on one connection, so the pragmas and the counts refer to the same
+// database state.
+func (d *Database) Vacuum(ctx context.Context, minFreeRatio float64) (*VacuumReport, error) {
+	report := &VacuumReport{}
+	err := d.DB.WithContext(ctx).Connection(func(tx *gorm.DB) error {
+		return vacuum(tx, minFreeRatio, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func vacuum(tx *gorm.DB, minFreeRatio float64, report *VacuumReport) error {
+	if err := tx.Raw("PRAGMA page_size").Scan(&report.PageSize).Error; err != nil {
+		return err
+	}
+	if err := tx.Raw("PRAGMA page_count").Scan(&report.PageCount).Error; err != nil {
+		return err
+	}
+	if err := tx.Raw("PRAGMA freelist_count").Scan(&report.FreePages).Error; err != nil {
+		return err
+	}
+
+	if report.PageCount == 0 || float64(report.FreePages)/float64(report.PageCount) < minFreeRatio {
+		return nil
+	}
+
+	var mode int
+	if err := tx.Raw("PRAGMA auto_vacuum").Scan(&mode).Error; err != nil {
+		return err
+	}
+
+	if mode != autoVacuumIncremental {
+		if err := tx.Exec(fmt.Sprintf("PRAGMA auto_vacuum = %d", autoVacuumIncremental)).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("VACUUM").Error; err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		report.FullVacuum = true
+	} else if err := incrementalVacuum(tx); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	var remaining int64
+	if err := tx.Raw("PRAGMA freelist_count").Scan(&remaining).Error; err != nil {
+		return err
+	}
+	report.ReclaimedPages = report.FreePages - remaining
+	return nil
+}
+
+// incrementalVacuum frees one page per result row, so the rows have to be
+// read to the end for the pragma to complete
+func incrementalVacuum(db *gorm.DB) error {
+	rows, err := db.Raw("PRAGMA incremental_vacuum").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return rows.Err()
+}
@@ -0,0 +1,12 @@
+-- Create app_settings_history table for keeping previous setting versions
+CREATE TABLE IF NOT EXISTS app_settings_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    type VARCHAR(50),
+    operation VARCHAR(20) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for listing history of a key
+CREATE INDEX IF NOT EXISTS idx_app_settings_history_key ON app_settings_history(key, id);
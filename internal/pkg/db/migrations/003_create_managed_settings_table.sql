@@ -0,0 +1,8 @@
+-- Create managed_settings table for caching the tenant settings policy
+CREATE TABLE IF NOT EXISTS managed_settings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key VARCHAR(255) NOT NULL UNIQUE,
+    value TEXT,
+    type VARCHAR(50) DEFAULT 'string',
+    fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
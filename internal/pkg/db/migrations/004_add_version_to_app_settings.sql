@@ -0,0 +1,2 @@
+-- Add version column to app_settings for optimistic concurrency control
+ALTER TABLE app_settings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+-- Create job_runs table for keeping cron job execution history
+CREATE TABLE IF NOT EXISTS job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    error TEXT,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+-- Create indexes for listing runs per job and pruning old runs
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name ON job_runs(job_name, started_at);
+CREATE INDEX IF NOT EXISTS idx_job_runs_started_at ON job_runs(started_at);
@@ -0,0 +1,7 @@
+-- Create job_leases table so that only one app instance runs a job at a time
+CREATE TABLE IF NOT EXISTS job_leases (
+    job_name VARCHAR(255) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
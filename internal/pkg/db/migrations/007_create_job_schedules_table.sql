@@ -0,0 +1,6 @@
+-- Create job_schedules table for catching up missed cron job runs
+CREATE TABLE IF NOT EXISTS job_schedules (
+    job_name VARCHAR(255) PRIMARY KEY,
+    last_run_at DATETIME NOT NULL,
+    updated_at DATETIME
+);
@@ -0,0 +1,21 @@
+-- Create tasks table for the persistent background task queue
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type VARCHAR(100) NOT NULL,
+    payload TEXT,
+    priority INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    run_at DATETIME NOT NULL,
+    locked_by VARCHAR(255),
+    locked_until DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME,
+    finished_at DATETIME
+);
+
+-- Create indexes for dequeuing by priority and listing by status
+CREATE INDEX IF NOT EXISTS idx_tasks_dequeue ON tasks(status, priority DESC, run_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_type ON tasks(type);
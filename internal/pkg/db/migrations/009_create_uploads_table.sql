@@ -0,0 +1,19 @@
+-- Create uploads table for resuming chunked uploads
+CREATE TABLE IF NOT EXISTS uploads (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path TEXT NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    endpoint TEXT NOT NULL,
+    upload_url TEXT,
+    metadata TEXT,
+    size INTEGER NOT NULL,
+    upload_offset INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    last_error TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    completed_at DATETIME
+);
+
+-- Create index for finding the upload of a file to resume
+CREATE INDEX IF NOT EXISTS idx_uploads_fingerprint ON uploads(fingerprint, status);
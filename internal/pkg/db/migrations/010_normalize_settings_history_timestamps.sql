@@ -0,0 +1,4 @@
+-- Store setting history timestamps in UTC so they compare correctly as text
+UPDATE app_settings_history
+SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at)
+WHERE created_at IS NOT NULL;
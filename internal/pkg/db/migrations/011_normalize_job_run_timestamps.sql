@@ -0,0 +1,8 @@
+-- Store job run timestamps in UTC so they sort and compare correctly as text
+UPDATE job_runs
+SET started_at = strftime('%Y-%m-%d %H:%M:%f+00:00', started_at)
+WHERE started_at IS NOT NULL;
+
+UPDATE job_runs
+SET finished_at = strftime('%Y-%m-%d %H:%M:%f+00:00', finished_at)
+WHERE finished_at IS NOT NULL;
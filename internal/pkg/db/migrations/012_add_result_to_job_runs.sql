@@ -0,0 +1,2 @@
+-- Add result column to job_runs for the summary a job reports, e.g. how much it cleaned up
+ALTER TABLE job_runs ADD COLUMN result TEXT;
@@ -0,0 +1,189 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoBaseURL is returned for relative requests while no base URL is set
+var ErrNoBaseURL = errors.New("API base URL is not configured")
+
+// APIClient sends requests to the backend. Relative URLs are resolved
+// against the base URL, default headers are added to every request and
+// connections are shared between requests.
+type APIClient struct {
+	mu      sync.RWMutex
+	baseURL *url.URL
+	headers http.Header
+	timeout time.Duration
+	retry   *HTTPRetryConfig
+	client  *http.Client
+}
+
+// APIClientOption configures an APIClient when it is created
+type APIClientOption func(*APIClient)
+
+// WithDefaultHeader adds a header sent with every request; request headers
+// with the same key take precedence
+func WithDefaultHeader(key, value string) APIClientOption {
+	return func(c *APIClient) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithDefaultTimeout sets the timeout of requests that do not set their own;
+// uploads of streamed files are not bounded by it
+func WithDefaultTimeout(timeout time.Duration) APIClientOption {
+	return func(c *APIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry sets the retries of requests that do not set their own
+func WithRetry(retry HTTPRetryConfig) APIClientOption {
+	return func(c *APIClient) {
+		c.retry = &retry
+	}
+}
+
+// WithTransport replaces the shared transport, e.g. to use a proxy
+func WithTransport(transport http.RoundTripper) APIClientOption {
+	return func(c *APIClient) {
+		c.client = &http.Client{Transport: transport}
+	}
+}
+
+// NewAPIClient creates a new APIClient; an empty baseURL leaves the client
+// unconfigured until SetBaseURL is called
+func NewAPIClient(baseURL string, opts ...APIClientOption) (*APIClient, error) {
+	client := &APIClient{
+		headers: http.Header{},
+		timeout: DefaultHTTPTimeout,
+		client:  sharedClient,
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if err := client.SetBaseURL(baseURL); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// SetBaseURL changes the URL relative requests are resolved against;
+// requests already sent are not affected
+func (c *APIClient) SetBaseURL(baseURL string) error {
+	var parsed *url.URL
+	if strings.TrimSpace(baseURL) != "" {
+		var err error
+		if parsed, err = SanitizeURL(baseURL); err != nil {
+			return fmt.Errorf("invalid base URL: %w", err)
+		}
+		parsed.RawQuery = ""
+		parsed.Fragment = ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = parsed
+	return nil
+}
+
+// BaseURL returns the configured base URL, or an empty string
+func (c *APIClient) BaseURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.baseURL == nil {
+		return ""
+	}
+	return c.baseURL.String()
+}
+
+// SetHeader sets a default header, e.g. after the user logged in
+func (c *APIClient) SetHeader(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers.Set(key, value)
+}
+
+// DelHeader removes a default header
+func (c *APIClient) DelHeader(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers.Del(key)
+}
+
+// ResolveURL returns the absolute URL of a request path. Paths are joined
+// to the base URL path, so "/tenants" with base "https://host/api" gives
+// "https://host/api/tenants". Absolute URLs are returned unchanged.
+func (c *APIClient) ResolveURL(path string) (string, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	if ref.IsAbs() {
+		return path, nil
+	}
+
+	c.mu.RLock()
+	base := c.baseURL
+	c.mu.RUnlock()
+
+	if base == nil {
+		return "", ErrNoBaseURL
+	}
+
+	// The escaped forms are joined as given: escapes such as %2F stay
+	// intact and ".." segments are left for the server to interpret
+	resolved := *base
+	if ref.Path != "" {
+		resolved.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(ref.Path, "/")
+		resolved.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(ref.EscapedPath(), "/")
+	}
+	resolved.RawQuery = ref.RawQuery
+	resolved.Fragment = ""
+	resolved.RawFragment = ""
+	return resolved.String(), nil
+}
+
+// Request sends a request; payload.URL may be relative to the base URL.
+// config may be nil and is not modified.
+func (c *APIClient) Request(payload *HTTPRequestPayload, config *HTTPRequestConfig) (*HTTPAPIResponse, error) {
+	resolved, err := c.ResolveURL(payload.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	request := *payload
+	request.URL = resolved
+
+	var requestConfig HTTPRequestConfig
+	if config != nil {
+		requestConfig = *config
+	}
+
+	c.mu.RLock()
+	headers := c.headers.Clone()
+	// Left to sendAttempt, which gives streamed uploads no timeout
+	requestConfig.defaultTimeout = c.timeout
+	if requestConfig.Retry == nil {
+		requestConfig.Retry = c.retry
+	}
+	client := c.client
+	c.mu.RUnlock()
+
+	for key, values := range requestConfig.Headers {
+		headers[key] = append([]string(nil), values...)
+	}
+	requestConfig.Headers = headers
+
+	return doRequest(client, &request, &requestConfig)
+}
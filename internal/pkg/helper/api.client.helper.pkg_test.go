@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	types "onx-screen-record/internal/common/type"
+)
+
+func TestAPIClientStreamedUploadOutlivesDefaultTimeout(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	content := bytes.Repeat([]byte("frame"), 64<<10)
+
+	received := make(chan int, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading slowly keeps the upload going past the client timeout
+		time.Sleep(delay)
+		if r.Header.Get("Content-Type") == enum.ApplicationJSON.ToString() {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		received <- len(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewAPIClient(server.URL, WithDefaultTimeout(delay/3))
+	if err != nil {
+		t.Fatalf("NewAPIClient: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.mp4")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.Request(&HTTPRequestPayload{
+		Method: enum.POST,
+		URL:    "/recordings",
+		Body: map[string]interface{}{
+			"file": types.StreamedFile{Path: path, OriginalName: "recording.mp4"},
+		},
+	}, &HTTPRequestConfig{
+		Headers: http.Header{"Content-Type": {enum.MultipartForm.ToString()}},
+	})
+	if err != nil {
+		t.Fatalf("streamed upload: %v", err)
+	}
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusCreated)
+	}
+	if got := <-received; got != len(content) {
+		t.Fatalf("server received %d bytes, want %d", got, len(content))
+	}
+
+	// Buffered requests are still bounded by the client default
+	_, err = client.Request(&HTTPRequestPayload{
+		Method: enum.POST,
+		URL:    "/recordings",
+		Body:   map[string]string{"name": "recording.mp4"},
+	}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("buffered request error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
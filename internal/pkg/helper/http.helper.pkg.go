@@ -9,14 +9,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"net"
 	"net/http"
-	"net/textproto"
 	"net/url"
 	"onx-screen-record/internal/common/enum"
-	types "onx-screen-record/internal/common/type"
+	"onx-screen-record/internal/pkg/backoff"
 	"onx-screen-record/internal/pkg/logger"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,7 +31,8 @@ type HTTPRequestPayload struct {
 	Method enum.HTTPMethodEnum
 	URL    string
 	Body   interface{}
-	Params map[string]string
+	// Params are added to the URL query; repeated keys are kept
+	Params url.Values
 }
 
 type HTTPRequestConfig struct {
@@ -39,8 +40,80 @@ type HTTPRequestConfig struct {
 	Headers   http.Header
 	Auth      *BasicAuthConfig
 	HTTPAgent *http.Transport
+	// Timeout bounds each attempt including reading the response. When
+	// zero it is DefaultHTTPTimeout, or the APIClient default timeout,
+	// except for uploads of streamed files which are only bounded by Ctx.
+	// Ctx bounds the request with its retries.
+	Timeout time.Duration
+	// Retry enables retries of transient failures, nil disables them
+	Retry *HTTPRetryConfig
+	// IdempotencyKey is sent as the Idempotency-Key header and allows
+	// retrying methods that are not idempotent, such as POST
+	IdempotencyKey string
+	// OnProgress is called while the body is sent with the bytes sent so
+	// far and the body size, -1 when unknown. It restarts with each retry.
+	OnProgress func(sent, total int64)
+
+	// defaultTimeout replaces DefaultHTTPTimeout when Timeout is zero
+	defaultTimeout time.Duration
 }
 
+// HTTPRetryConfig describes how transient failures are retried: network
+// errors and the status codes in retryableStatusCodes
+type HTTPRetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// Backoff is the delay between attempts, DefaultHTTPRetryBackoff when zero
+	Backoff backoff.Policy
+	// MaxRetryAfter caps the delay a server may request with Retry-After;
+	// a longer delay returns the response instead. DefaultHTTPMaxRetryAfter
+	// when zero.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultHTTPTimeout bounds requests that do not set their own timeout
+const DefaultHTTPTimeout = 60 * time.Second
+
+// DefaultHTTPMaxRetryAfter is the longest Retry-After delay waited for
+const DefaultHTTPMaxRetryAfter = 2 * time.Minute
+
+// DefaultHTTPRetryBackoff is the delay between attempts of a request
+var DefaultHTTPRetryBackoff = backoff.Policy{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// retryableStatusCodes are responses worth retrying as the server may
+// answer differently a moment later
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// sharedTransport is reused by every request so connections are kept alive
+// between calls
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// sharedClient has no timeout of its own, requests are bounded through
+// their context instead
+var sharedClient = &http.Client{Transport: sharedTransport}
+
 type BasicAuthConfig struct {
 	Username string
 	Password string
@@ -50,20 +123,190 @@ func HTTPRequest(
 	payload *HTTPRequestPayload,
 	config *HTTPRequestConfig,
 ) (*HTTPAPIResponse, error) {
-	requestBody, err := handleRequestBody(payload, config)
+	return doRequest(sharedClient, payload, config)
+}
+
+func doRequest(client *http.Client, payload *HTTPRequestPayload, config *HTTPRequestConfig) (*HTTPAPIResponse, error) {
+	if config.Headers == nil {
+		config.Headers = http.Header{}
+	}
+
+	body, err := handleRequestBody(payload, config)
 	if err != nil {
 		logger.Error.Println("Error handling request body:", err.Error())
 		return nil, err
 	}
 
-	req, client, err := prepareRequest(payload, requestBody, config)
+	if config.IdempotencyKey != "" {
+		config.Headers.Set("Idempotency-Key", config.IdempotencyKey)
+	}
+
+	ctx := config.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	maxRetries := 0
+	if config.Retry != nil && isRetrySafe(payload.Method, config) && (body == nil || body.replayable) {
+		maxRetries = config.Retry.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := sendAttempt(ctx, client, payload, body, config)
+		if attempt >= maxRetries || ctx.Err() != nil {
+			return response, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			// Only transport errors are retried
+			var prepareErr *prepareError
+			var parseErr *parseError
+			if errors.As(err, &prepareErr) || errors.As(err, &parseErr) {
+				return nil, err
+			}
+			delay = config.Retry.delay(attempt+1, nil)
+			logger.Warning.Printf("%s %s failed, retrying in %v: %v", payload.Method, payload.URL, delay, err)
+		case retryableStatusCodes[response.StatusCode]:
+			delay = config.Retry.delay(attempt+1, response.Headers)
+			if delay < 0 {
+				return response, nil
+			}
+			logger.Warning.Printf("%s %s returned %d, retrying in %v", payload.Method, payload.URL, response.StatusCode, delay)
+		default:
+			return response, nil
+		}
+
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return response, err
+		}
+	}
+}
+
+// prepareError marks a request that could not be built, which no retry fixes
+type prepareError struct {
+	err error
+}
+
+func (e *prepareError) Error() string {
+	return e.err.Error()
+}
+
+func (e *prepareError) Unwrap() error {
+	return e.err
+}
+
+// parseError marks a response that was received but could not be parsed,
+// which no retry fixes either
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// sendAttempt sends the request once, bounded by the attempt timeout
+func sendAttempt(ctx context.Context, client *http.Client, payload *HTTPRequestPayload, body *bodySource, config *HTTPRequestConfig) (*HTTPAPIResponse, error) {
+	timeout := config.Timeout
+	if timeout <= 0 && (body == nil || !body.streamed) {
+		timeout = DefaultHTTPTimeout
+		if config.defaultTimeout > 0 {
+			timeout = config.defaultTimeout
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		opened, err := body.open(ctx)
+		if err != nil {
+			return nil, &prepareError{err: err}
+		}
+		reader = opened
+		if config.OnProgress != nil {
+			reader = newProgressReader(opened, body.length, config.OnProgress)
+		}
+	}
+
+	req, client, err := prepareRequest(ctx, client, payload, reader, config)
 	if err != nil {
 		logger.Error.Println("Error preparing request:", err.Error())
-		return nil, err
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, &prepareError{err: err}
+	}
+	if body != nil {
+		switch {
+		case body.length == 0:
+			req.Body = http.NoBody
+		case body.length > 0:
+			req.ContentLength = body.length
+		}
+		if body.replayable {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return body.open(ctx)
+			}
+		}
 	}
 	return executeRequest(req, client)
 }
 
+// isRetrySafe reports whether sending the request twice is harmless
+func isRetrySafe(method enum.HTTPMethodEnum, config *HTTPRequestConfig) bool {
+	return method.IsIdempotent() || config.Headers.Get("Idempotency-Key") != ""
+}
+
+// delay returns the wait before the given retry; a Retry-After header takes
+// precedence over the backoff. It is negative when the server asks to wait
+// longer than MaxRetryAfter.
+func (r *HTTPRetryConfig) delay(retry int, headers http.Header) time.Duration {
+	if retryAfter, ok := parseRetryAfter(headers.Get("Retry-After"), time.Now()); ok {
+		maxRetryAfter := r.MaxRetryAfter
+		if maxRetryAfter <= 0 {
+			maxRetryAfter = DefaultHTTPMaxRetryAfter
+		}
+		if retryAfter > maxRetryAfter {
+			return -1
+		}
+		return retryAfter
+	}
+
+	policy := r.Backoff
+	if policy == (backoff.Policy{}) {
+		policy = DefaultHTTPRetryBackoff
+	}
+	return policy.Delay(retry)
+}
+
+// parseRetryAfter parses a Retry-After value, either seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
 func SanitizeURL(rawURL string) (*url.URL, error) {
 	rawURL = strings.TrimSpace(rawURL)
 
@@ -91,44 +334,91 @@ func SanitizeURL(rawURL string) (*url.URL, error) {
 	return parsedURL, nil
 }
 
-func handleRequestBody(payload *HTTPRequestPayload, config *HTTPRequestConfig) (io.Reader, error) {
+// bodySource produces the request body of every attempt
+type bodySource struct {
+	open func(ctx context.Context) (io.ReadCloser, error)
+	// length is the body size, -1 when unknown
+	length int64
+	// replayable bodies can be opened again for a retry or redirect
+	replayable bool
+	// streamed bodies are read from files while they are sent
+	streamed bool
+}
+
+// bufferedBody reads a small body into memory so it can be sent repeatedly
+func bufferedBody(reader io.Reader) (*bodySource, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &bodySource{
+		open: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+		length:     int64(len(data)),
+		replayable: true,
+	}, nil
+}
+
+func handleRequestBody(payload *HTTPRequestPayload, config *HTTPRequestConfig) (*bodySource, error) {
 	var requestBody io.Reader
 	var err error
 
-	if payload.Method == enum.GET {
+	// Bodies are only sent when given and meaningful for the method
+	if !payload.Method.AllowsBody() || payload.Body == nil {
 		return nil, nil
 	} else {
 		switch config.Headers.Get("Content-Type") {
 		case enum.ApplicationXform.ToString():
-			if payload.Body != nil {
-				requestBody, err = createFormURLEncodedBody(payload.Body)
-			}
+			requestBody, err = createFormURLEncodedBody(payload.Body)
 		case enum.MultipartForm.ToString():
-			if payload.Body != nil {
-				var ct string
-				requestBody, ct, err = createMultipartBody(payload.Body)
-				config.Headers.Set("Content-Type", ct)
+			source, ct, err := createMultipartBody(payload.Body)
+			if err != nil {
+				return nil, err
 			}
+			config.Headers.Set("Content-Type", ct)
+			return source, nil
 		case enum.ApplicationJSON.ToString():
 			requestBody, err = createJSONBody(payload.Body)
 		case "":
 			config.Headers.Set("Content-Type", enum.ApplicationJSON.ToString())
 			requestBody, err = createJSONBody(payload.Body)
 		default:
-			return nil, errors.New("unsupported content type")
+			// Other content types are sent as is from raw bytes
+			raw, ok := dereferencePointer(payload.Body).([]byte)
+			if !ok {
+				return nil, errors.New("unsupported content type")
+			}
+			requestBody = bytes.NewReader(raw)
 		}
 	}
 
-	return requestBody, err
+	if err != nil {
+		return nil, err
+	}
+	return bufferedBody(requestBody)
 }
 
-func prepareRequest(payload *HTTPRequestPayload, body io.Reader, config *HTTPRequestConfig) (*http.Request, *http.Client, error) {
+func prepareRequest(ctx context.Context, client *http.Client, payload *HTTPRequestPayload, body io.Reader, config *HTTPRequestConfig) (*http.Request, *http.Client, error) {
 	sanitizedURL, err := SanitizeURL(payload.URL)
 	if err != nil {
 		return nil, nil, fmt.Errorf("invalid URL: %w", err)
 	}
+	if !payload.Method.IsValid() {
+		return nil, nil, fmt.Errorf("unsupported HTTP method: %q", payload.Method)
+	}
+
+	if len(payload.Params) > 0 {
+		query := sanitizedURL.Query()
+		for key, values := range payload.Params {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		sanitizedURL.RawQuery = query.Encode()
+	}
 
-	req, err := http.NewRequestWithContext(config.Ctx, payload.Method.ToString(), sanitizedURL.String(), body)
+	req, err := http.NewRequestWithContext(ctx, payload.Method.ToString(), sanitizedURL.String(), body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -141,10 +431,8 @@ func prepareRequest(payload *HTTPRequestPayload, body io.Reader, config *HTTPReq
 		req.SetBasicAuth(config.Auth.Username, config.Auth.Password)
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-
 	if config.HTTPAgent != nil {
-		client.Transport = config.HTTPAgent
+		client = &http.Client{Transport: config.HTTPAgent}
 	}
 
 	return req, client, nil
@@ -159,7 +447,7 @@ func executeRequest(req *http.Request, client *http.Client) (*HTTPAPIResponse, e
 
 	result, err := parseResponseBody(resp)
 	if err != nil {
-		return nil, err
+		return nil, &parseError{err: err}
 	}
 
 	return &HTTPAPIResponse{
@@ -175,6 +463,10 @@ func parseResponseBody(resp *http.Response) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	// HEAD responses, 204 and 304 have no body whatever their content type
+	if len(responseBody) == 0 {
+		return nil, nil
+	}
 
 	var result interface{}
 	switch {
@@ -239,75 +531,6 @@ func createFormURLEncodedBody(body interface{}) (io.Reader, error) {
 	return strings.NewReader(values.Encode()), nil
 }
 
-func createMultipartBody(body interface{}) (io.Reader, string, error) {
-	actualBody := dereferencePointer(body)
-	formData, ok := actualBody.(map[string]interface{})
-	if !ok {
-		form, err := JSONToStruct[map[string]interface{}](actualBody)
-		if err != nil || form == nil {
-			return nil, "", errors.New("body must be a map[string]interface{} for multipart/form-data content type")
-		}
-		formData = *form
-
-	}
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	for key, value := range formData {
-		switch v := value.(type) {
-		case string:
-			_ = writer.WriteField(key, v)
-		case []byte:
-			part, err := writer.CreateFormFile(key, key)
-			if err != nil {
-				return nil, "", err
-			}
-			_, err = part.Write(v)
-			if err != nil {
-				return nil, "", err
-			}
-		case []types.BufferedFile:
-			for _, v := range v {
-				fileField, err := writer.CreatePart(textproto.MIMEHeader{
-					"Content-Disposition": []string{fmt.Sprintf(`form-data; name=%q; filename=%q`, key, v.OriginalName)},
-					"Content-Type":        []string{v.MimeType},
-					"Content-Encoding":    []string{v.Encoding},
-					"Content-Length":      []string{fmt.Sprintf("%d", v.Size)},
-				})
-				if err != nil {
-					return nil, "", err
-				}
-				_, err = fileField.Write(v.Buffer)
-				if err != nil {
-					return nil, "", err
-				}
-			}
-		case types.BufferedFile:
-			fileField, err := writer.CreatePart(textproto.MIMEHeader{
-				"Content-Disposition": []string{fmt.Sprintf(`form-data; name=%q; filename=%q`, key, v.OriginalName)},
-				"Content-Type":        []string{v.MimeType},
-				"Content-Encoding":    []string{v.Encoding},
-				"Content-Length":      []string{fmt.Sprintf("%d", v.Size)},
-			})
-			if err != nil {
-				return nil, "", err
-			}
-			_, err = fileField.Write(v.Buffer)
-			if err != nil {
-				return nil, "", err
-			}
-		default:
-			return nil, "", errors.New("unsupported multipart data type")
-		}
-	}
-
-	err := writer.Close()
-	if err != nil {
-		return nil, "", err
-	}
-	return &buf, writer.FormDataContentType(), nil
-}
-
 func dereferencePointer(value interface{}) interface{} {
 	if value == nil {
 		return nil
@@ -348,3 +571,35 @@ func FileToBase64(rawURL string) (string, error) {
 	base64String := base64.StdEncoding.EncodeToString(data)
 	return base64String, nil
 }
+
+// progressInterval limits how often upload progress is reported
+const progressInterval = 100 * time.Millisecond
+
+// progressReader reports the bytes read from a request body
+type progressReader struct {
+	reader     io.ReadCloser
+	total      int64
+	sent       int64
+	reportedAt time.Time
+	onProgress func(sent, total int64)
+}
+
+func newProgressReader(reader io.ReadCloser, total int64, onProgress func(sent, total int64)) *progressReader {
+	onProgress(0, total)
+	return &progressReader{reader: reader, total: total, reportedAt: time.Now(), onProgress: onProgress}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.sent += int64(n)
+
+	if err == io.EOF || time.Since(r.reportedAt) >= progressInterval {
+		r.reportedAt = time.Now()
+		r.onProgress(r.sent, r.total)
+	}
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	return r.reader.Close()
+}
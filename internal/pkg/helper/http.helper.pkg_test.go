@@ -0,0 +1,213 @@
+package helper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	"onx-screen-record/internal/pkg/backoff"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Setup()
+	os.Exit(m.Run())
+}
+
+// recordedRequest is what the test server saw of a request
+type recordedRequest struct {
+	method        string
+	query         url.Values
+	body          string
+	contentLength int64
+}
+
+// newRecordingServer starts a server answering every request with handler
+// after recording it
+func newRecordingServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{
+			method:        r.Method,
+			query:         r.URL.Query(),
+			body:          string(body),
+			contentLength: r.ContentLength,
+		})
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), requests...)
+	}
+}
+
+func respondJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `{"ok":true}`)
+}
+
+func TestParamsMergeWithExistingQuery(t *testing.T) {
+	server, requests := newRecordingServer(t, respondJSON)
+
+	_, err := HTTPRequest(&HTTPRequestPayload{
+		Method: enum.GET,
+		URL:    server.URL + "/items?tag=a&page=1",
+		Params: url.Values{"tag": {"b", "c"}, "sort": {"name"}},
+	}, &HTTPRequestConfig{})
+	if err != nil {
+		t.Fatalf("HTTPRequest: %v", err)
+	}
+
+	got := requests()[0].query
+	want := url.Values{"tag": {"a", "b", "c"}, "page": {"1"}, "sort": {"name"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("query = %v, want %v", got, want)
+	}
+}
+
+func TestMethodsAndBodies(t *testing.T) {
+	tests := []struct {
+		method   enum.HTTPMethodEnum
+		wantBody string
+	}{
+		{method: enum.GET},
+		{method: enum.HEAD},
+		{method: enum.POST, wantBody: `{"name":"x"}`},
+		{method: enum.PUT, wantBody: `{"name":"x"}`},
+		{method: enum.PATCH, wantBody: `{"name":"x"}`},
+		{method: enum.DELETE, wantBody: `{"name":"x"}`},
+		{method: enum.OPTIONS, wantBody: `{"name":"x"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method.ToString(), func(t *testing.T) {
+			server, requests := newRecordingServer(t, respondJSON)
+
+			response, err := HTTPRequest(&HTTPRequestPayload{
+				Method: tt.method,
+				URL:    server.URL + "/items",
+				Body:   map[string]string{"name": "x"},
+			}, &HTTPRequestConfig{})
+			if err != nil {
+				t.Fatalf("HTTPRequest: %v", err)
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusOK)
+			}
+
+			got := requests()[0]
+			if got.method != tt.method.ToString() {
+				t.Fatalf("method = %s, want %s", got.method, tt.method)
+			}
+			if got.body != tt.wantBody {
+				t.Fatalf("body = %q, want %q", got.body, tt.wantBody)
+			}
+			if tt.wantBody == "" && got.contentLength > 0 {
+				t.Fatalf("Content-Length = %d, want no body", got.contentLength)
+			}
+		})
+	}
+}
+
+func TestEmptyResponsesParseToNil(t *testing.T) {
+	tests := []struct {
+		name   string
+		method enum.HTTPMethodEnum
+		status int
+	}{
+		{name: "HEAD", method: enum.HEAD, status: http.StatusOK},
+		{name: "204", method: enum.DELETE, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+			})
+
+			response, err := HTTPRequest(&HTTPRequestPayload{
+				Method: tt.method,
+				URL:    server.URL + "/items/1",
+			}, &HTTPRequestConfig{})
+			if err != nil {
+				t.Fatalf("HTTPRequest: %v", err)
+			}
+			if response.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", response.StatusCode, tt.status)
+			}
+			if response.Data != nil {
+				t.Fatalf("data = %#v, want nil", response.Data)
+			}
+		})
+	}
+}
+
+func TestParseErrorsAreNotRetried(t *testing.T) {
+	var hits atomic.Int32
+	server, _ := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":`)
+	})
+
+	_, err := HTTPRequest(&HTTPRequestPayload{
+		Method: enum.GET,
+		URL:    server.URL + "/items",
+	}, &HTTPRequestConfig{
+		Retry: &HTTPRetryConfig{
+			MaxRetries: 3,
+			Backoff:    backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1},
+		},
+	})
+	if err == nil {
+		t.Fatal("HTTPRequest succeeded with an invalid JSON response")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("server hit %d times, want 1", got)
+	}
+}
+
+func TestRetryableStatusIsRetried(t *testing.T) {
+	var hits atomic.Int32
+	server, _ := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		respondJSON(w, r)
+	})
+
+	response, err := HTTPRequest(&HTTPRequestPayload{
+		Method: enum.GET,
+		URL:    server.URL + "/items",
+	}, &HTTPRequestConfig{
+		Retry: &HTTPRetryConfig{
+			MaxRetries: 3,
+			Backoff:    backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("HTTPRequest: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || hits.Load() != 2 {
+		t.Fatalf("status = %d after %d hits, want %d after 2", response.StatusCode, hits.Load(), http.StatusOK)
+	}
+}
@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// InstanceID identifies this process among the app instances sharing the
+// database, e.g. as the owner of job leases and claimed tasks
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			instanceID = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+			return
+		}
+		instanceID = fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+	})
+	return instanceID
+}
@@ -0,0 +1,252 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"sort"
+
+	types "onx-screen-record/internal/common/type"
+)
+
+// multipartBody streams a multipart/form-data body. Streamed files are
+// copied into an io.Pipe while the request is sent, so their size does not
+// matter; everything else is small and written as is.
+type multipartBody struct {
+	boundary string
+	fields   map[string]interface{}
+	keys     []string
+	// streamed is the total size of the streamed files, -1 when unknown
+	streamed int64
+	// replayable is false once a file is read from a one-shot Reader
+	replayable bool
+}
+
+func createMultipartBody(body interface{}) (*bodySource, string, error) {
+	actualBody := dereferencePointer(body)
+	formData, ok := actualBody.(map[string]interface{})
+	if !ok {
+		form, err := JSONToStruct[map[string]interface{}](actualBody)
+		if err != nil || form == nil {
+			return nil, "", errors.New("body must be a map[string]interface{} for multipart/form-data content type")
+		}
+		formData = *form
+	}
+
+	m := &multipartBody{
+		boundary:   multipart.NewWriter(io.Discard).Boundary(),
+		fields:     make(map[string]interface{}, len(formData)),
+		replayable: true,
+	}
+
+	// Sizes of files on disk are resolved up front for the Content-Length
+	for key, value := range formData {
+		switch v := value.(type) {
+		case types.StreamedFile:
+			file, err := m.resolve(v)
+			if err != nil {
+				return nil, "", err
+			}
+			m.fields[key] = file
+		case []types.StreamedFile:
+			files := make([]types.StreamedFile, len(v))
+			for i := range v {
+				file, err := m.resolve(v[i])
+				if err != nil {
+					return nil, "", err
+				}
+				files[i] = file
+			}
+			m.fields[key] = files
+		default:
+			m.fields[key] = value
+		}
+		m.keys = append(m.keys, key)
+	}
+	sort.Strings(m.keys)
+
+	// A dry run validates the fields and measures everything but the files
+	counter := &countingWriter{}
+	if err := m.write(context.Background(), counter, true); err != nil {
+		return nil, "", err
+	}
+
+	length := int64(-1)
+	if m.streamed >= 0 {
+		length = counter.n + m.streamed
+	}
+
+	source := &bodySource{
+		length:     length,
+		replayable: m.replayable,
+		streamed:   m.streamed != 0,
+		open:       m.open,
+	}
+	return source, "multipart/form-data; boundary=" + m.boundary, nil
+}
+
+// resolve checks a streamed file and adds its size to the streamed total
+func (m *multipartBody) resolve(file types.StreamedFile) (types.StreamedFile, error) {
+	switch {
+	case file.Path != "":
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return file, fmt.Errorf("cannot stream file: %w", err)
+		}
+		if info.IsDir() {
+			return file, fmt.Errorf("cannot stream file: %s is a directory", file.Path)
+		}
+		file.Size = info.Size()
+	case file.Reader != nil:
+		m.replayable = false
+	default:
+		return file, errors.New("streamed file needs a Path or a Reader")
+	}
+
+	if file.Size <= 0 && file.Path == "" {
+		m.streamed = -1
+	} else if m.streamed >= 0 {
+		m.streamed += file.Size
+	}
+	return file, nil
+}
+
+// open starts writing the body into a pipe; the writer stops when ctx is
+// done or the transport closes the returned reader
+func (m *multipartBody) open(ctx context.Context) (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(m.write(ctx, writer, false))
+	}()
+	return reader, nil
+}
+
+// write writes the body; a dry run skips the content of streamed files
+func (m *multipartBody) write(ctx context.Context, w io.Writer, dry bool) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+
+	for _, key := range m.keys {
+		switch v := m.fields[key].(type) {
+		case string:
+			if err := writer.WriteField(key, v); err != nil {
+				return err
+			}
+		case []byte:
+			part, err := writer.CreateFormFile(key, key)
+			if err != nil {
+				return err
+			}
+			if _, err = part.Write(v); err != nil {
+				return err
+			}
+		case []types.BufferedFile:
+			for _, file := range v {
+				if err := writeBufferedPart(writer, key, file); err != nil {
+					return err
+				}
+			}
+		case types.BufferedFile:
+			if err := writeBufferedPart(writer, key, v); err != nil {
+				return err
+			}
+		case []types.StreamedFile:
+			for _, file := range v {
+				if err := writeStreamedPart(ctx, writer, key, file, dry); err != nil {
+					return err
+				}
+			}
+		case types.StreamedFile:
+			if err := writeStreamedPart(ctx, writer, key, v, dry); err != nil {
+				return err
+			}
+		default:
+			return errors.New("unsupported multipart data type")
+		}
+	}
+
+	return writer.Close()
+}
+
+func writeBufferedPart(writer *multipart.Writer, key string, file types.BufferedFile) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": []string{fmt.Sprintf(`form-data; name=%q; filename=%q`, key, file.OriginalName)},
+		"Content-Type":        []string{file.MimeType},
+		"Content-Encoding":    []string{file.Encoding},
+		"Content-Length":      []string{fmt.Sprintf("%d", file.Size)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(file.Buffer)
+	return err
+}
+
+func writeStreamedPart(ctx context.Context, writer *multipart.Writer, key string, file types.StreamedFile, dry bool) error {
+	mimeType := file.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Disposition": []string{fmt.Sprintf(`form-data; name=%q; filename=%q`, key, file.OriginalName)},
+		"Content-Type":        []string{mimeType},
+	}
+	if file.Size > 0 {
+		header.Set("Content-Length", fmt.Sprintf("%d", file.Size))
+	}
+
+	part, err := writer.CreatePart(header)
+	if err != nil || dry {
+		return err
+	}
+
+	if file.Path == "" {
+		_, err = io.Copy(part, &contextReader{ctx: ctx, reader: file.Reader})
+		return err
+	}
+
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// The Content-Length was computed from the size seen up front, so a
+	// file that changed since must not send more or fewer bytes
+	written, err := io.Copy(part, &contextReader{ctx: ctx, reader: io.LimitReader(f, file.Size)})
+	if err != nil {
+		return err
+	}
+	if written != file.Size {
+		return fmt.Errorf("cannot stream file: %s shrank from %d to %d bytes", file.Path, file.Size, written)
+	}
+	return nil
+}
+
+// contextReader stops reading once ctx is done
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -1,9 +1,11 @@
 package pathHelper
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 type PathHelper struct {
@@ -81,3 +83,51 @@ func (p *PathHelper) GetPath(dirType, filename string) (string, error) {
 	}
 	return filepath.Join(dir, filename), nil
 }
+
+// CleanTempDataDir removes files in the temporary data directory last
+// modified before the given time, then empty directories, and returns the
+// number of files and bytes removed
+func (p *PathHelper) CleanTempDataDir(before time.Time) (int, int64, error) {
+	root, err := p.GetTempDataDir()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var files int
+	var bytes int64
+	var dirs []string
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Keep going when a file disappears or is not readable
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			return nil
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if err := os.Remove(path); err == nil {
+			files++
+			bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return files, bytes, err
+	}
+
+	// Deepest directories first, os.Remove fails on non-empty ones
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+
+	return files, bytes, nil
+}
@@ -0,0 +1,406 @@
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/backoff"
+	"onx-screen-record/internal/pkg/helper"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// Handler processes a task; returning an error retries the task with
+// backoff until its attempts are exhausted
+type Handler func(ctx context.Context, task *models.Task) error
+
+// Store persists the queue; it is implemented by repository.TaskRepository
+type Store interface {
+	Enqueue(task *models.Task) error
+	// Dequeue returns nil when no task is available
+	Dequeue(owner string, visibility time.Duration) (*models.Task, error)
+	Extend(id uint, owner string, visibility time.Duration) error
+	Complete(id uint, owner string) error
+	Fail(id uint, owner string, message string, retryAt time.Time) error
+	// Release requeues a task without counting the attempt
+	Release(id uint, owner string, message string) error
+	Bury(id uint, owner string, message string) error
+}
+
+const (
+	// DefaultWorkers is the number of tasks processed concurrently
+	DefaultWorkers = 2
+
+	// DefaultMaxAttempts is how often a task runs before it is dead-lettered
+	DefaultMaxAttempts = 5
+
+	// DefaultVisibilityTimeout is how long a claimed task stays hidden from
+	// other workers without a heartbeat; a task of a crashed instance is
+	// picked up again once it expires
+	DefaultVisibilityTimeout = 5 * time.Minute
+
+	// DefaultPollInterval is how often idle workers look for due tasks
+	DefaultPollInterval = 5 * time.Second
+)
+
+// ErrQueueClosed is returned once the queue has been shut down
+var ErrQueueClosed = errors.New("task queue is shut down")
+
+// PanicError is recorded for a task whose handler panicked
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so the task goes to the dead-letter state right away
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Option configures a queue when it is created
+type Option func(*Queue)
+
+// WithWorkers sets the number of tasks processed concurrently
+func WithWorkers(workers int) Option {
+	return func(q *Queue) {
+		if workers > 0 {
+			q.workers = workers
+		}
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed task stays hidden from
+// other workers between heartbeats
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(q *Queue) {
+		if timeout > 0 {
+			q.visibility = timeout
+		}
+	}
+}
+
+// WithPollInterval sets how often idle workers look for due tasks
+func WithPollInterval(interval time.Duration) Option {
+	return func(q *Queue) {
+		if interval > 0 {
+			q.pollInterval = interval
+		}
+	}
+}
+
+// WithBackoff sets the delay between attempts of a failed task
+func WithBackoff(policy backoff.Policy) Option {
+	return func(q *Queue) {
+		q.backoff = policy
+	}
+}
+
+// EnqueueOption configures a task when it is enqueued
+type EnqueueOption func(*models.Task)
+
+// WithPriority sets the task priority; higher priorities run first
+func WithPriority(priority int) EnqueueOption {
+	return func(t *models.Task) {
+		t.Priority = priority
+	}
+}
+
+// WithMaxAttempts sets how often the task runs before it is dead-lettered
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return func(t *models.Task) {
+		if attempts > 0 {
+			t.MaxAttempts = attempts
+		}
+	}
+}
+
+// WithRunAt postpones the task until the given time
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return func(t *models.Task) {
+		t.RunAt = runAt
+	}
+}
+
+// Queue runs persisted tasks on a pool of workers
+type Queue struct {
+	store        Store
+	owner        string
+	workers      int
+	visibility   time.Duration
+	pollInterval time.Duration
+	backoff      backoff.Policy
+
+	handlers map[string]Handler
+	mu       sync.RWMutex
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	started  bool
+	closed   bool
+}
+
+// NewQueue creates a new task queue; workers start with Start
+func NewQueue(ctx context.Context, store Store, opts ...Option) *Queue {
+	ctx, cancel := context.WithCancel(ctx)
+
+	queue := &Queue{
+		store:        store,
+		owner:        helper.InstanceID(),
+		workers:      DefaultWorkers,
+		visibility:   DefaultVisibilityTimeout,
+		pollInterval: DefaultPollInterval,
+		backoff:      backoff.Default,
+		handlers:     make(map[string]Handler),
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+
+	for _, opt := range opts {
+		opt(queue)
+	}
+
+	return queue
+}
+
+// Register sets the handler of a task type
+func (q *Queue) Register(taskType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = handler
+}
+
+// Enqueue stores a task for background processing; payload is stored as
+// JSON unless it already is a string
+func (q *Queue) Enqueue(taskType string, payload interface{}, opts ...EnqueueOption) (*models.Task, error) {
+	if q.isClosed() {
+		return nil, ErrQueueClosed
+	}
+
+	encoded, ok := payload.(string)
+	if !ok && payload != nil {
+		var err error
+		if encoded, err = helper.JSONToString(payload); err != nil {
+			return nil, fmt.Errorf("failed to encode payload of task '%s': %w", taskType, err)
+		}
+	}
+
+	task := &models.Task{
+		Type:        taskType,
+		Payload:     encoded,
+		MaxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(task)
+	}
+
+	if err := q.store.Enqueue(task); err != nil {
+		return nil, err
+	}
+	logger.Debug.Printf("Task %d '%s' enqueued", task.ID, taskType)
+
+	q.notify()
+	return task, nil
+}
+
+// Notify wakes an idle worker, e.g. after a task was retried
+func (q *Queue) Notify() {
+	q.notify()
+}
+
+// Start launches the workers
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started || q.closed {
+		return
+	}
+	q.started = true
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	logger.Info.Printf("Task queue started with %d workers", q.workers)
+}
+
+// Shutdown stops taking tasks and waits for running tasks until ctx is
+// done. Tasks whose handler returns meanwhile are requeued without counting
+// the attempt; tasks still running afterwards are picked up again on the
+// next start once their visibility timeout expires.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info.Println("Task queue shut down")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) isClosed() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.closed
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work claims and processes tasks until the queue shuts down
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for q.ctx.Err() == nil {
+		task, err := q.store.Dequeue(q.owner, q.visibility)
+		if err != nil {
+			logger.Error.Printf("Failed to dequeue task: %v", err)
+		}
+		if task != nil {
+			q.process(task)
+			continue
+		}
+
+		timer := time.NewTimer(q.pollInterval)
+		select {
+		case <-q.ctx.Done():
+			timer.Stop()
+			return
+		case <-q.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// process runs a claimed task and records its outcome
+func (q *Queue) process(task *models.Task) {
+	// A task whose worker died on its last attempt is not run again
+	if task.Attempts > task.MaxAttempts {
+		q.bury(task, fmt.Sprintf("abandoned after %d attempts: %s", task.MaxAttempts, task.LastError))
+		return
+	}
+
+	q.mu.RLock()
+	handler, ok := q.handlers[task.Type]
+	q.mu.RUnlock()
+
+	if !ok {
+		q.bury(task, fmt.Sprintf("no handler registered for task type '%s'", task.Type))
+		return
+	}
+
+	logger.Debug.Printf("Processing task %d '%s' (attempt %d of %d)", task.ID, task.Type, task.Attempts, task.MaxAttempts)
+
+	done := make(chan struct{})
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		q.heartbeat(task, done)
+	}()
+
+	start := time.Now()
+	err := q.call(handler, task)
+	close(done)
+	<-heartbeat
+
+	var permanent *permanentError
+	var panicked *PanicError
+	switch {
+	case err == nil:
+		logger.Debug.Printf("Task %d '%s' completed in %v", task.ID, task.Type, time.Since(start))
+		if err := q.store.Complete(task.ID, q.owner); err != nil {
+			logger.Error.Printf("Failed to complete task %d: %v", task.ID, err)
+		}
+	case q.ctx.Err() != nil:
+		logger.Info.Printf("Task %d '%s' interrupted by shutdown, requeued", task.ID, task.Type)
+		if err := q.store.Release(task.ID, q.owner, err.Error()); err != nil {
+			logger.Error.Printf("Failed to requeue task %d: %v", task.ID, err)
+		}
+	case errors.As(err, &permanent), errors.As(err, &panicked), task.Attempts >= task.MaxAttempts:
+		q.bury(task, err.Error())
+	default:
+		delay := q.backoff.Delay(task.Attempts)
+		logger.Warning.Printf("Task %d '%s' failed, retrying in %v: %v", task.ID, task.Type, delay, err)
+		if err := q.store.Fail(task.ID, q.owner, err.Error(), time.Now().Add(delay)); err != nil {
+			logger.Error.Printf("Failed to reschedule task %d: %v", task.ID, err)
+		}
+	}
+}
+
+// call invokes the handler, turning a panic into a *PanicError
+func (q *Queue) call(handler Handler, task *models.Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorWithStack("Task %d '%s' panicked: %v", task.ID, task.Type, r)
+			err = &PanicError{Value: r}
+		}
+	}()
+
+	return handler(q.ctx, task)
+}
+
+// heartbeat extends the visibility timeout every third of it until done
+// is closed, so long tasks are not taken over by another worker
+func (q *Queue) heartbeat(task *models.Task, done <-chan struct{}) {
+	ticker := time.NewTicker(q.visibility / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := q.store.Extend(task.ID, q.owner, q.visibility); err != nil {
+				logger.Warning.Printf("Failed to extend task %d: %v", task.ID, err)
+			}
+		}
+	}
+}
+
+func (q *Queue) bury(task *models.Task, message string) {
+	logger.Error.Printf("Task %d '%s' moved to dead letter: %s", task.ID, task.Type, message)
+	if err := q.store.Bury(task.ID, q.owner, message); err != nil {
+		logger.Error.Printf("Failed to bury task %d: %v", task.ID, err)
+	}
+}
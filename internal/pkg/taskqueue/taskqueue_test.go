@@ -0,0 +1,309 @@
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/backoff"
+	"onx-screen-record/internal/pkg/db"
+	"onx-screen-record/internal/pkg/logger"
+	"onx-screen-record/internal/repository"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const waitTimeout = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	logger.Setup()
+	os.Exit(m.Run())
+}
+
+// newTestStore returns a task repository on an in-memory database with
+// every migration applied
+func newTestStore(t *testing.T) (*repository.TaskRepository, *gorm.DB) {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := database.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.NewMigrator(database).Run(); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return repository.NewTaskRepository(database), database
+}
+
+// newTestQueue returns a queue polling often and retrying right away; it
+// is shut down when the test ends
+func newTestQueue(t *testing.T, store Store, opts ...Option) *Queue {
+	t.Helper()
+
+	opts = append([]Option{
+		WithWorkers(2),
+		WithPollInterval(10 * time.Millisecond),
+		WithBackoff(backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1}),
+	}, opts...)
+	queue := NewQueue(context.Background(), store, opts...)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+		defer cancel()
+		queue.Shutdown(ctx)
+	})
+	return queue
+}
+
+// waitStatus waits until the task reaches status and returns it
+func waitStatus(t *testing.T, store *repository.TaskRepository, id uint, status enum.TaskStatusEnum) *models.Task {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		task, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%d): %v", id, err)
+		}
+		if task.Status == status {
+			return task
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %d is %s, want %s", id, task.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueueRunsTask(t *testing.T) {
+	store, _ := newTestStore(t)
+	queue := newTestQueue(t, store)
+
+	payloads := make(chan string, 1)
+	queue.Register("greet", func(ctx context.Context, task *models.Task) error {
+		payloads <- task.Payload
+		return nil
+	})
+	queue.Start()
+
+	task, err := queue.Enqueue("greet", map[string]string{"name": "world"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	done := waitStatus(t, store, task.ID, enum.TASK_SUCCEEDED)
+
+	if payload := <-payloads; payload != `{"name":"world"}` {
+		t.Fatalf("payload = %s, want the encoded map", payload)
+	}
+	if done.Attempts != 1 || done.FinishedAt == nil || done.LockedBy != "" {
+		t.Fatalf("task = %+v, want finished after one attempt and unlocked", done)
+	}
+}
+
+func TestQueueDeadLettersAfterMaxAttempts(t *testing.T) {
+	store, _ := newTestStore(t)
+	queue := newTestQueue(t, store)
+
+	var calls atomic.Int32
+	queue.Register("flaky", func(ctx context.Context, task *models.Task) error {
+		calls.Add(1)
+		return errors.New("service unavailable")
+	})
+	queue.Start()
+
+	task, err := queue.Enqueue("flaky", nil, WithMaxAttempts(3))
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	dead := waitStatus(t, store, task.ID, enum.TASK_DEAD)
+
+	if calls.Load() != 3 || dead.Attempts != 3 || dead.LastError != "service unavailable" {
+		t.Fatalf("task = %+v after %d calls, want dead after 3 attempts", dead, calls.Load())
+	}
+}
+
+func TestQueueDeadLettersWithoutRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler Handler
+		want    string
+	}{
+		{
+			name: "permanent",
+			handler: func(ctx context.Context, task *models.Task) error {
+				return Permanent(errors.New("invalid payload"))
+			},
+			want: "invalid payload",
+		},
+		{
+			name: "panic",
+			handler: func(ctx context.Context, task *models.Task) error {
+				panic("boom")
+			},
+			want: "panic: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStore(t)
+			queue := newTestQueue(t, store)
+			queue.Register("broken", tt.handler)
+			queue.Start()
+
+			task, err := queue.Enqueue("broken", nil)
+			if err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+			dead := waitStatus(t, store, task.ID, enum.TASK_DEAD)
+
+			if dead.Attempts != 1 || dead.LastError != tt.want {
+				t.Fatalf("task = %+v, want dead after one attempt with %q", dead, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueBuriesUnknownType(t *testing.T) {
+	store, _ := newTestStore(t)
+	queue := newTestQueue(t, store)
+	queue.Start()
+
+	task, err := queue.Enqueue("unknown", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	dead := waitStatus(t, store, task.ID, enum.TASK_DEAD)
+
+	if !strings.Contains(dead.LastError, "no handler") {
+		t.Fatalf("last error = %q, want the missing handler", dead.LastError)
+	}
+}
+
+func TestQueueHeartbeatKeepsTaskClaimed(t *testing.T) {
+	store, _ := newTestStore(t)
+	visibility := 300 * time.Millisecond
+	queue := newTestQueue(t, store, WithVisibilityTimeout(visibility))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	queue.Register("slow", func(ctx context.Context, task *models.Task) error {
+		close(started)
+		<-release
+		return nil
+	})
+	queue.Start()
+
+	task, err := queue.Enqueue("slow", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(waitTimeout):
+		t.Fatal("task did not start")
+	}
+
+	// Well past the original visibility timeout the task is still locked
+	time.Sleep(3 * visibility)
+	if claimed, err := store.Dequeue("other", visibility); err != nil || claimed != nil {
+		t.Fatalf("Dequeue by another worker = %+v, %v, want the task kept claimed", claimed, err)
+	}
+	running, err := store.Get(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.LockedUntil == nil || !running.LockedUntil.After(time.Now()) {
+		t.Fatalf("locked until %v, want extended by the heartbeat", running.LockedUntil)
+	}
+
+	close(release)
+	if done := waitStatus(t, store, task.ID, enum.TASK_SUCCEEDED); done.Attempts != 1 {
+		t.Fatalf("task = %+v, want completed by the first attempt", done)
+	}
+}
+
+func TestQueueShutdownReleasesRunningTask(t *testing.T) {
+	store, _ := newTestStore(t)
+	queue := NewQueue(context.Background(), store, WithPollInterval(10*time.Millisecond))
+
+	started := make(chan struct{})
+	queue.Register("long", func(ctx context.Context, task *models.Task) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	queue.Start()
+
+	task, err := queue.Enqueue("long", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(waitTimeout):
+		t.Fatal("task did not start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	if err := queue.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	released, err := store.Get(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released.Status != enum.TASK_PENDING || released.Attempts != 0 || released.LockedBy != "" {
+		t.Fatalf("task = %+v after shutdown, want pending without the attempt", released)
+	}
+	if _, err := queue.Enqueue("long", nil); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("Enqueue after shutdown = %v, want %v", err, ErrQueueClosed)
+	}
+}
+
+func TestQueueBuriesAbandonedTask(t *testing.T) {
+	store, database := newTestStore(t)
+
+	// A worker claimed the task on its last attempt and crashed
+	task := &models.Task{Type: "crashy", MaxAttempts: 1}
+	if err := store.Enqueue(task); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if claimed, err := store.Dequeue("crashed", time.Minute); err != nil || claimed == nil {
+		t.Fatalf("Dequeue = %+v, %v, want the task", claimed, err)
+	}
+	expired := time.Now().UTC().Add(-time.Second)
+	if err := database.Model(&models.Task{}).Where("id = ?", task.ID).Update("locked_until", expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	queue := newTestQueue(t, store)
+	var calls atomic.Int32
+	queue.Register("crashy", func(ctx context.Context, task *models.Task) error {
+		calls.Add(1)
+		return nil
+	})
+	queue.Start()
+
+	dead := waitStatus(t, store, task.ID, enum.TASK_DEAD)
+	if calls.Load() != 0 || !strings.Contains(dead.LastError, "abandoned") {
+		t.Fatalf("task = %+v after %d calls, want buried as abandoned without running", dead, calls.Load())
+	}
+}
@@ -0,0 +1,452 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/backoff"
+	"onx-screen-record/internal/pkg/helper"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+// The client speaks the tus resumable upload protocol 1.0 with the checksum
+// extension: a POST creates an upload session, HEAD returns its offset and
+// every PATCH appends a chunk at that offset.
+const (
+	tusVersion         = "1.0.0"
+	offsetContentType  = "application/offset+octet-stream"
+	statusChecksumFail = 460
+)
+
+const (
+	// DefaultChunkSize is the size of every PATCH request
+	DefaultChunkSize = 8 << 20
+
+	// DefaultMaxFailures is how many attempts in a row may fail without
+	// progress before the upload gives up
+	DefaultMaxFailures = 5
+
+	// minUploadRate is the slowest upload rate in bytes per second a chunk
+	// is given time for, on top of helper.DefaultHTTPTimeout
+	minUploadRate = 64 << 10
+)
+
+var (
+	// ErrSessionExpired is returned when the server no longer knows the
+	// upload session; the upload starts again with a new session
+	ErrSessionExpired = errors.New("upload session expired")
+
+	// ErrOffsetMismatch is returned when the server reports an offset the
+	// client did not expect
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+
+	// ErrFileChanged is returned when the file was modified or truncated
+	// during the upload; retrying cannot succeed, the file has to be
+	// uploaded again
+	ErrFileChanged = errors.New("file changed during upload")
+)
+
+// API sends the requests of an upload; it is implemented by helper.APIClient
+type API interface {
+	ResolveURL(path string) (string, error)
+	Request(payload *helper.HTTPRequestPayload, config *helper.HTTPRequestConfig) (*helper.HTTPAPIResponse, error)
+}
+
+// Store persists upload state; it is implemented by repository.UploadRepository
+type Store interface {
+	Create(upload *models.Upload) error
+	// FindResumable returns nil when there is no unfinished upload
+	FindResumable(endpoint, fingerprint string) (*models.Upload, error)
+	SaveProgress(id uint, uploadURL string, offset int64) error
+	Complete(id uint) error
+	Fail(id uint, message string) error
+}
+
+// Option configures an Uploader when it is created
+type Option func(*Uploader)
+
+// WithChunkSize sets the size of every PATCH request
+func WithChunkSize(size int64) Option {
+	return func(u *Uploader) {
+		if size > 0 {
+			u.chunkSize = size
+		}
+	}
+}
+
+// WithMaxFailures sets how many attempts in a row may fail without progress
+func WithMaxFailures(failures int) Option {
+	return func(u *Uploader) {
+		if failures > 0 {
+			u.maxFailures = failures
+		}
+	}
+}
+
+// WithBackoff sets the delay between failed attempts
+func WithBackoff(policy backoff.Policy) Option {
+	return func(u *Uploader) {
+		u.backoff = policy
+	}
+}
+
+// Request describes a file to upload
+type Request struct {
+	Path string
+	// Endpoint creates upload sessions, relative to the API base URL
+	Endpoint string
+	// Metadata is sent with the new session, e.g. the file name
+	Metadata map[string]string
+	// OnProgress is called with the bytes confirmed by the server
+	OnProgress func(sent, total int64)
+}
+
+// Uploader uploads files in chunks and resumes interrupted uploads from the
+// offset confirmed by the server
+type Uploader struct {
+	api         API
+	store       Store
+	chunkSize   int64
+	maxFailures int
+	backoff     backoff.Policy
+}
+
+// NewUploader creates a new Uploader
+func NewUploader(api API, store Store, opts ...Option) *Uploader {
+	uploader := &Uploader{
+		api:         api,
+		store:       store,
+		chunkSize:   DefaultChunkSize,
+		maxFailures: DefaultMaxFailures,
+		backoff:     backoff.Default,
+	}
+
+	for _, opt := range opts {
+		opt(uploader)
+	}
+
+	return uploader
+}
+
+// Upload uploads a file, resuming an earlier upload of the same unchanged
+// file. When ctx is cancelled the upload stays resumable.
+func (u *Uploader) Upload(ctx context.Context, req Request) (*models.Upload, error) {
+	path, err := filepath.Abs(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("cannot upload %s: is a directory", path)
+	}
+
+	fingerprint := Fingerprint(path, info)
+	upload, err := u.store.FindResumable(req.Endpoint, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload state: %w", err)
+	}
+	if upload == nil {
+		upload = &models.Upload{
+			FilePath:    path,
+			Fingerprint: fingerprint,
+			Endpoint:    req.Endpoint,
+			Metadata:    encodeMetadata(req.Metadata),
+			Size:        info.Size(),
+		}
+		if err := u.store.Create(upload); err != nil {
+			return nil, fmt.Errorf("failed to save upload state: %w", err)
+		}
+	} else {
+		logger.Info.Printf("Resuming upload %d of %s at %d of %d bytes", upload.ID, path, upload.Offset, upload.Size)
+	}
+
+	if err := u.run(ctx, upload, req.OnProgress); err != nil {
+		if ctx.Err() == nil {
+			if err := u.store.Fail(upload.ID, err.Error()); err != nil {
+				logger.Error.Printf("Failed to save state of upload %d: %v", upload.ID, err)
+			}
+		}
+		return upload, err
+	}
+
+	if err := u.store.Complete(upload.ID); err != nil {
+		return upload, fmt.Errorf("failed to save upload state: %w", err)
+	}
+	logger.Info.Printf("Upload %d of %s completed", upload.ID, path)
+	return upload, nil
+}
+
+// run sends the chunks until the server has the whole file; attempts fail
+// at most maxFailures times without the confirmed offset moving forward
+func (u *Uploader) run(ctx context.Context, upload *models.Upload, onProgress func(sent, total int64)) error {
+	file, err := os.Open(upload.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	synced := false
+	failures := 0
+	confirmed := upload.Offset
+	for {
+		err := u.step(ctx, file, upload, &synced)
+		if err == nil {
+			// Resyncing the offset succeeds even when the chunk keeps
+			// failing, so only progress resets the failures
+			if upload.Offset > confirmed {
+				confirmed = upload.Offset
+				failures = 0
+			}
+			if onProgress != nil {
+				onProgress(upload.Offset, upload.Size)
+			}
+			if synced && upload.Offset >= upload.Size {
+				return nil
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrFileChanged) || errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		// The offset is queried again before the next chunk
+		synced = false
+		if errors.Is(err, ErrSessionExpired) {
+			logger.Warning.Printf("Session of upload %d expired, starting again", upload.ID)
+			upload.UploadURL = ""
+			upload.Offset = 0
+			confirmed = 0
+		}
+
+		failures++
+		if failures >= u.maxFailures {
+			return fmt.Errorf("upload failed after %d attempts: %w", failures, err)
+		}
+
+		delay := u.backoff.Delay(failures)
+		logger.Warning.Printf("Upload %d failed at %d bytes, retrying in %v: %v", upload.ID, upload.Offset, delay, err)
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// step creates the session, syncs the offset or sends one chunk
+func (u *Uploader) step(ctx context.Context, file *os.File, upload *models.Upload, synced *bool) error {
+	switch {
+	case upload.UploadURL == "":
+		uploadURL, err := u.create(ctx, upload)
+		if err != nil {
+			return err
+		}
+		upload.UploadURL = uploadURL
+		upload.Offset = 0
+		*synced = true
+	case !*synced:
+		offset, err := u.offset(ctx, upload)
+		if err != nil {
+			return err
+		}
+		upload.Offset = offset
+		*synced = true
+	default:
+		if err := checkUnchanged(upload); err != nil {
+			return err
+		}
+		offset, err := u.sendChunk(ctx, file, upload)
+		if err != nil {
+			return err
+		}
+		upload.Offset = offset
+	}
+
+	if err := u.store.SaveProgress(upload.ID, upload.UploadURL, upload.Offset); err != nil {
+		logger.Error.Printf("Failed to save progress of upload %d: %v", upload.ID, err)
+	}
+	return nil
+}
+
+// create opens a new upload session and returns its absolute URL
+func (u *Uploader) create(ctx context.Context, upload *models.Upload) (string, error) {
+	headers := tusHeaders()
+	headers.Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	if upload.Metadata != "" {
+		headers.Set("Upload-Metadata", upload.Metadata)
+	}
+
+	resp, err := u.api.Request(&helper.HTTPRequestPayload{
+		Method: enum.POST,
+		URL:    upload.Endpoint,
+	}, &helper.HTTPRequestConfig{Ctx: ctx, Headers: headers})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to create upload session: status %d", resp.StatusCode)
+	}
+
+	location := resp.Headers.Get("Location")
+	if location == "" {
+		return "", errors.New("failed to create upload session: no Location header")
+	}
+
+	// Location may be relative to the endpoint
+	endpoint, err := u.api.ResolveURL(upload.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload session location %q: %w", location, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// offset returns the number of bytes the server has received
+func (u *Uploader) offset(ctx context.Context, upload *models.Upload) (int64, error) {
+	resp, err := u.api.Request(&helper.HTTPRequestPayload{
+		Method: enum.HEAD,
+		URL:    upload.UploadURL,
+	}, &helper.HTTPRequestConfig{Ctx: ctx, Headers: tusHeaders()})
+	if err != nil {
+		return 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound, http.StatusGone, http.StatusForbidden:
+		return 0, ErrSessionExpired
+	default:
+		return 0, fmt.Errorf("failed to query upload offset: status %d", resp.StatusCode)
+	}
+
+	offset, err := parseOffset(resp.Headers)
+	if err != nil {
+		return 0, err
+	}
+	if offset > upload.Size {
+		return 0, fmt.Errorf("%w: server has %d of %d bytes", ErrOffsetMismatch, offset, upload.Size)
+	}
+	return offset, nil
+}
+
+// sendChunk sends the chunk at the current offset with its checksum and
+// returns the new offset
+func (u *Uploader) sendChunk(ctx context.Context, file *os.File, upload *models.Upload) (int64, error) {
+	length := min(u.chunkSize, upload.Size-upload.Offset)
+	chunk := make([]byte, length)
+	if _, err := file.ReadAt(chunk, upload.Offset); err != nil {
+		return 0, fmt.Errorf("failed to read chunk at %d: %w", upload.Offset, err)
+	}
+
+	sum := sha256.Sum256(chunk)
+	headers := tusHeaders()
+	headers.Set("Content-Type", offsetContentType)
+	headers.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	headers.Set("Upload-Checksum", "sha256 "+base64.StdEncoding.EncodeToString(sum[:]))
+
+	resp, err := u.api.Request(&helper.HTTPRequestPayload{
+		Method: enum.PATCH,
+		URL:    upload.UploadURL,
+		Body:   chunk,
+	}, &helper.HTTPRequestConfig{Ctx: ctx, Headers: headers, Timeout: chunkTimeout(length)})
+	if err != nil {
+		return 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+	case http.StatusNotFound, http.StatusGone, http.StatusForbidden:
+		return 0, ErrSessionExpired
+	case http.StatusConflict:
+		return 0, fmt.Errorf("%w: server rejected offset %d", ErrOffsetMismatch, upload.Offset)
+	case statusChecksumFail:
+		return 0, fmt.Errorf("checksum of chunk at %d rejected", upload.Offset)
+	default:
+		return 0, fmt.Errorf("failed to upload chunk at %d: status %d", upload.Offset, resp.StatusCode)
+	}
+
+	offset, err := parseOffset(resp.Headers)
+	if err != nil {
+		return 0, err
+	}
+	if offset != upload.Offset+length {
+		return 0, fmt.Errorf("%w: expected %d, server has %d", ErrOffsetMismatch, upload.Offset+length, offset)
+	}
+	return offset, nil
+}
+
+// chunkTimeout bounds sending a chunk of length bytes, so large chunks on a
+// slow link are not cut off by the default request timeout
+func chunkTimeout(length int64) time.Duration {
+	return helper.DefaultHTTPTimeout + time.Duration(length/minUploadRate)*time.Second
+}
+
+func tusHeaders() http.Header {
+	headers := http.Header{}
+	headers.Set("Tus-Resumable", tusVersion)
+	return headers
+}
+
+func parseOffset(headers http.Header) (int64, error) {
+	offset, err := strconv.ParseInt(headers.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid Upload-Offset header %q", headers.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// checkUnchanged returns ErrFileChanged when the file no longer matches the
+// fingerprint it had when the upload started
+func checkUnchanged(upload *models.Upload) error {
+	info, err := os.Stat(upload.FilePath)
+	if err != nil {
+		return err
+	}
+	if Fingerprint(upload.FilePath, info) != upload.Fingerprint {
+		return fmt.Errorf("%w: %s", ErrFileChanged, upload.FilePath)
+	}
+	return nil
+}
+
+// Fingerprint identifies a file by path, size and modification time, so a
+// changed file is uploaded again from the start
+func Fingerprint(path string, info os.FileInfo) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeMetadata encodes the Upload-Metadata header, base64 values sorted
+// by key
+func encodeMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,500 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/backoff"
+	"onx-screen-record/internal/pkg/helper"
+	"onx-screen-record/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Setup()
+	os.Exit(m.Run())
+}
+
+// Faults the test server injects into a given PATCH request
+const (
+	// faultDrop stores the chunk but answers 500, as if the response was lost
+	faultDrop = "drop"
+	// faultConflict answers 409 as if another client moved the offset
+	faultConflict = "conflict"
+	// faultChecksum corrupts the received chunk, so the checksum fails
+	faultChecksum = "checksum"
+	// faultExpire forgets the session before answering
+	faultExpire = "expire"
+)
+
+type tusSession struct {
+	length int64
+	data   []byte
+}
+
+// tusServer is a minimal tus 1.0 server with the checksum extension. Upload
+// sessions are created under /api/uploads and live under /api/files.
+type tusServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sessions map[string]*tusSession
+	nextID   int
+	created  int
+	heads    int
+	patches  int
+	metadata string
+	// faults maps the number of a PATCH request, from 1, to a fault
+	faults map[int]string
+}
+
+func newTUSServer(t *testing.T) *tusServer {
+	t.Helper()
+
+	s := &tusServer{sessions: make(map[string]*tusSession), faults: make(map[int]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tusServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/api/uploads" {
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.created++
+		s.metadata = r.Header.Get("Upload-Metadata")
+		id := s.addSessionLocked(length, nil)
+		// A relative location, resolved against the endpoint
+		w.Header().Set("Location", "files/"+id)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	id, ok := strings.CutPrefix(r.URL.Path, "/api/files/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		s.heads++
+		session := s.sessions[id]
+		if session == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(session.data)))
+		w.Header().Set("Upload-Length", strconv.FormatInt(session.length, 10))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPatch:
+		s.patches++
+		s.patch(w, r, id, s.faults[s.patches])
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *tusServer) patch(w http.ResponseWriter, r *http.Request, id string, fault string) {
+	if fault == faultExpire {
+		delete(s.sessions, id)
+	}
+	session := s.sessions[id]
+	if session == nil {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	if r.Header.Get("Content-Type") != offsetContentType {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.Atoi(r.Header.Get("Upload-Offset"))
+	if err != nil || offset != len(session.data) || fault == faultConflict {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	chunk, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if fault == faultChecksum && len(chunk) > 0 {
+		chunk[0] ^= 0xff
+	}
+	sum := sha256.Sum256(chunk)
+	if r.Header.Get("Upload-Checksum") != "sha256 "+base64.StdEncoding.EncodeToString(sum[:]) {
+		w.WriteHeader(statusChecksumFail)
+		return
+	}
+	if int64(offset+len(chunk)) > session.length {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	session.data = append(session.data, chunk...)
+	if fault == faultDrop {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.Itoa(len(session.data)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addSession creates a session holding data and returns its URL
+func (s *tusServer) addSession(length int64, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.URL + "/api/files/" + s.addSessionLocked(length, data)
+}
+
+func (s *tusServer) addSessionLocked(length int64, data []byte) string {
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.sessions[id] = &tusSession{length: length, data: append([]byte(nil), data...)}
+	return id
+}
+
+// session returns the content received for a session URL
+func (s *tusServer) session(uploadURL string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.sessions[strings.TrimPrefix(uploadURL, s.URL+"/api/files/")]
+	if session == nil {
+		return nil
+	}
+	return append([]byte(nil), session.data...)
+}
+
+func (s *tusServer) counts() (created, heads, patches int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.created, s.heads, s.patches
+}
+
+// memStore keeps upload state in memory
+type memStore struct {
+	mu      sync.Mutex
+	uploads map[uint]*models.Upload
+	nextID  uint
+}
+
+func newMemStore() *memStore {
+	return &memStore{uploads: make(map[uint]*models.Upload)}
+}
+
+func (s *memStore) Create(upload *models.Upload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	upload.ID = s.nextID
+	upload.Status = enum.UPLOAD_PENDING
+	stored := *upload
+	s.uploads[upload.ID] = &stored
+	return nil
+}
+
+func (s *memStore) FindResumable(endpoint, fingerprint string) (*models.Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *models.Upload
+	for _, upload := range s.uploads {
+		if upload.Endpoint == endpoint && upload.Fingerprint == fingerprint && upload.Status != enum.UPLOAD_COMPLETED {
+			if found == nil || upload.ID > found.ID {
+				found = upload
+			}
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	upload := *found
+	return &upload, nil
+}
+
+func (s *memStore) SaveProgress(id uint, uploadURL string, offset int64) error {
+	return s.update(id, func(upload *models.Upload) {
+		upload.UploadURL = uploadURL
+		upload.Offset = offset
+		upload.Status = enum.UPLOAD_PENDING
+		upload.LastError = ""
+	})
+}
+
+func (s *memStore) Complete(id uint) error {
+	return s.update(id, func(upload *models.Upload) {
+		upload.Status = enum.UPLOAD_COMPLETED
+		upload.LastError = ""
+	})
+}
+
+func (s *memStore) Fail(id uint, message string) error {
+	return s.update(id, func(upload *models.Upload) {
+		upload.Status = enum.UPLOAD_FAILED
+		upload.LastError = message
+	})
+}
+
+func (s *memStore) update(id uint, apply func(*models.Upload)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload := s.uploads[id]
+	if upload == nil {
+		return fmt.Errorf("upload %d not found", id)
+	}
+	apply(upload)
+	return nil
+}
+
+func (s *memStore) get(id uint) models.Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.uploads[id]
+}
+
+func newTestUploader(t *testing.T, server *tusServer, store Store) *Uploader {
+	t.Helper()
+
+	api, err := helper.NewAPIClient(server.URL + "/api")
+	if err != nil {
+		t.Fatalf("NewAPIClient: %v", err)
+	}
+	return NewUploader(api, store,
+		WithChunkSize(4),
+		WithMaxFailures(3),
+		WithBackoff(backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1}),
+	)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "recording.mp4")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// expectUploaded checks the upload completed with the whole file on the server
+func expectUploaded(t *testing.T, server *tusServer, store *memStore, upload *models.Upload, content string) {
+	t.Helper()
+
+	stored := store.get(upload.ID)
+	if stored.Status != enum.UPLOAD_COMPLETED {
+		t.Fatalf("upload status = %s (%s), want %s", stored.Status, stored.LastError, enum.UPLOAD_COMPLETED)
+	}
+	if stored.Offset != int64(len(content)) {
+		t.Fatalf("upload offset = %d, want %d", stored.Offset, len(content))
+	}
+	if got := string(server.session(stored.UploadURL)); got != content {
+		t.Fatalf("server received %q, want %q", got, content)
+	}
+}
+
+func TestUploadFresh(t *testing.T) {
+	server := newTUSServer(t)
+	store := newMemStore()
+	path := writeFile(t, "0123456789")
+
+	var progress []int64
+	upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+		Path:       path,
+		Endpoint:   "/uploads",
+		Metadata:   map[string]string{"filename": "recording.mp4"},
+		OnProgress: func(sent, total int64) { progress = append(progress, sent) },
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	expectUploaded(t, server, store, upload, "0123456789")
+	if want := server.URL + "/api/files/1"; store.get(upload.ID).UploadURL != want {
+		t.Fatalf("upload URL = %s, want the resolved Location %s", store.get(upload.ID).UploadURL, want)
+	}
+	if want := "filename " + base64.StdEncoding.EncodeToString([]byte("recording.mp4")); server.metadata != want {
+		t.Fatalf("Upload-Metadata = %q, want %q", server.metadata, want)
+	}
+	if got := fmt.Sprint(progress); got != "[0 4 8 10]" {
+		t.Fatalf("progress = %s, want [0 4 8 10]", got)
+	}
+	if created, heads, patches := server.counts(); created != 1 || heads != 0 || patches != 3 {
+		t.Fatalf("created %d sessions, sent %d HEAD and %d PATCH, want 1, 0 and 3", created, heads, patches)
+	}
+}
+
+func TestUploadRecoversFromPatchFaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		fault       string
+		wantCreated int
+		wantPatches int
+	}{
+		// The server kept the chunk, the offset from HEAD skips it
+		{name: "failed PATCH resumes from server offset", fault: faultDrop, wantCreated: 1, wantPatches: 3},
+		{name: "409 offset mismatch", fault: faultConflict, wantCreated: 1, wantPatches: 4},
+		{name: "460 checksum failure", fault: faultChecksum, wantCreated: 1, wantPatches: 4},
+		// The file is sent again from the start in a new session
+		{name: "expired session", fault: faultExpire, wantCreated: 2, wantPatches: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTUSServer(t)
+			server.faults[2] = tt.fault
+			store := newMemStore()
+			path := writeFile(t, "0123456789")
+
+			upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+				Path:     path,
+				Endpoint: "/uploads",
+			})
+			if err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+
+			expectUploaded(t, server, store, upload, "0123456789")
+			created, heads, patches := server.counts()
+			if created != tt.wantCreated || patches != tt.wantPatches {
+				t.Fatalf("created %d sessions and sent %d PATCH, want %d and %d", created, patches, tt.wantCreated, tt.wantPatches)
+			}
+			if tt.fault != faultExpire && heads != 1 {
+				t.Fatalf("sent %d HEAD, want 1 to resync the offset", heads)
+			}
+		})
+	}
+}
+
+func TestUploadGivesUpAfterMaxFailures(t *testing.T) {
+	server := newTUSServer(t)
+	for i := 1; i <= 10; i++ {
+		server.faults[i] = faultChecksum
+	}
+	store := newMemStore()
+	path := writeFile(t, "0123456789")
+
+	upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+		Path:     path,
+		Endpoint: "/uploads",
+	})
+	if err == nil {
+		t.Fatal("Upload succeeded although every checksum failed")
+	}
+	if stored := store.get(upload.ID); stored.Status != enum.UPLOAD_FAILED || stored.LastError == "" {
+		t.Fatalf("upload status = %s (%q), want %s with the error", stored.Status, stored.LastError, enum.UPLOAD_FAILED)
+	}
+}
+
+func TestUploadZeroByteFile(t *testing.T) {
+	server := newTUSServer(t)
+	store := newMemStore()
+	path := writeFile(t, "")
+
+	upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+		Path:     path,
+		Endpoint: "/uploads",
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	expectUploaded(t, server, store, upload, "")
+	if created, _, patches := server.counts(); created != 1 || patches != 0 {
+		t.Fatalf("created %d sessions and sent %d PATCH, want 1 and 0", created, patches)
+	}
+}
+
+func TestUploadResumesPersistedUpload(t *testing.T) {
+	server := newTUSServer(t)
+	store := newMemStore()
+	path := writeFile(t, "0123456789")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// State left behind by a previous app run that stopped after a chunk
+	persisted := &models.Upload{
+		FilePath:    path,
+		Fingerprint: Fingerprint(path, info),
+		Endpoint:    "/uploads",
+		UploadURL:   server.addSession(10, []byte("0123")),
+		Size:        10,
+		Offset:      4,
+	}
+	store.Create(persisted)
+	store.Fail(persisted.ID, "app closed")
+
+	upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+		Path:     path,
+		Endpoint: "/uploads",
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	if upload.ID != persisted.ID {
+		t.Fatalf("uploaded as %d, want the persisted upload %d", upload.ID, persisted.ID)
+	}
+	expectUploaded(t, server, store, upload, "0123456789")
+	if created, heads, patches := server.counts(); created != 0 || heads != 1 || patches != 2 {
+		t.Fatalf("created %d sessions, sent %d HEAD and %d PATCH, want 0, 1 and 2", created, heads, patches)
+	}
+}
+
+func TestUploadStopsWhenFileChanges(t *testing.T) {
+	server := newTUSServer(t)
+	store := newMemStore()
+	path := writeFile(t, "0123456789")
+
+	upload, err := newTestUploader(t, server, store).Upload(context.Background(), Request{
+		Path:     path,
+		Endpoint: "/uploads",
+		OnProgress: func(sent, total int64) {
+			if sent == 4 {
+				if err := os.WriteFile(path, []byte("012"), 0o600); err != nil {
+					t.Error(err)
+				}
+			}
+		},
+	})
+	if !errors.Is(err, ErrFileChanged) {
+		t.Fatalf("Upload error = %v, want %v", err, ErrFileChanged)
+	}
+	if stored := store.get(upload.ID); stored.Status != enum.UPLOAD_FAILED {
+		t.Fatalf("upload status = %s, want %s", stored.Status, enum.UPLOAD_FAILED)
+	}
+	if _, _, patches := server.counts(); patches != 1 {
+		t.Fatalf("sent %d PATCH, want 1 before the change was noticed", patches)
+	}
+}
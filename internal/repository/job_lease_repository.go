@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+)
+
+// JobLeaseRepository handles cron job lease database operations
+type JobLeaseRepository struct {
+	db *gorm.DB
+}
+
+// NewJobLeaseRepository creates a new JobLeaseRepository instance
+func NewJobLeaseRepository(db *gorm.DB) *JobLeaseRepository {
+	return &JobLeaseRepository{db: db}
+}
+
+// Acquire takes or extends the lease of a job for owner. It succeeds when
+// the lease is free, expired or already held by owner, in a single
+// statement so concurrent instances cannot both win.
+func (r *JobLeaseRepository) Acquire(jobName, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	result := r.db.Exec(`
+		INSERT INTO job_leases (job_name, owner, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(job_name) DO UPDATE SET
+			owner = excluded.owner,
+			acquired_at = CASE WHEN job_leases.owner = excluded.owner THEN job_leases.acquired_at ELSE excluded.acquired_at END,
+			expires_at = excluded.expires_at
+		WHERE job_leases.owner = excluded.owner OR job_leases.expires_at < ?`,
+		jobName, owner, now, now.Add(ttl), now,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Release gives up the lease of a job if owner still holds it
+func (r *JobLeaseRepository) Release(jobName, owner string) error {
+	return r.db.Where("job_name = ? AND owner = ?", jobName, owner).Delete(&models.JobLease{}).Error
+}
+
+// GetAll returns every lease, including expired ones
+func (r *JobLeaseRepository) GetAll() ([]models.JobLease, error) {
+	var leases []models.JobLease
+	err := r.db.Order("job_name").Find(&leases).Error
+	return leases, err
+}
+
+// PruneExpired deletes leases that expired before the given time
+func (r *JobLeaseRepository) PruneExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before.UTC()).Delete(&models.JobLease{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+)
+
+// JobRunRepository handles cron job run history database operations
+type JobRunRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRunRepository creates a new JobRunRepository instance
+func NewJobRunRepository(db *gorm.DB) *JobRunRepository {
+	return &JobRunRepository{db: db}
+}
+
+// RecordRun stores a finished job run
+func (r *JobRunRepository) RecordRun(run *models.JobRun) error {
+	// Stored as text, so timestamps must share a zone to compare correctly
+	run.StartedAt = run.StartedAt.UTC()
+	run.FinishedAt = run.FinishedAt.UTC()
+	return r.db.Create(run).Error
+}
+
+// GetHistory returns the latest runs of a job, or of every job when
+// jobName is empty, newest first
+func (r *JobRunRepository) GetHistory(jobName string, limit int) ([]models.JobRun, error) {
+	query := r.db.Order("started_at DESC, id DESC")
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var runs []models.JobRun
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// PruneBefore deletes runs started before the given time
+func (r *JobRunRepository) PruneBefore(before time.Time) (int64, error) {
+	result := r.db.Where("started_at < ?", before.UTC()).Delete(&models.JobRun{})
+	return result.RowsAffected, result.Error
+}
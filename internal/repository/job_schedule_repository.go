@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobScheduleRepository handles cron job schedule state database operations
+type JobScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewJobScheduleRepository creates a new JobScheduleRepository instance
+func NewJobScheduleRepository(db *gorm.DB) *JobScheduleRepository {
+	return &JobScheduleRepository{db: db}
+}
+
+// GetLastRun returns the last activation of a job, or the zero time when
+// the job never ran
+func (r *JobScheduleRepository) GetLastRun(jobName string) (time.Time, error) {
+	var schedule models.JobSchedule
+	err := r.db.Where("job_name = ?", jobName).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.LastRunAt, nil
+}
+
+// SaveLastRun stores the last activation of a job
+func (r *JobScheduleRepository) SaveLastRun(jobName string, at time.Time) error {
+	schedule := models.JobSchedule{
+		JobName:   jobName,
+		LastRunAt: at.UTC(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_run_at", "updated_at"}),
+	}).Create(&schedule).Error
+}
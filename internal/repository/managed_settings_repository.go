@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+)
+
+// ManagedSettingsRepository handles the cached tenant settings policy
+type ManagedSettingsRepository struct {
+	db *gorm.DB
+}
+
+// NewManagedSettingsRepository creates a new ManagedSettingsRepository instance
+func NewManagedSettingsRepository(db *gorm.DB) *ManagedSettingsRepository {
+	return &ManagedSettingsRepository{db: db}
+}
+
+// GetAll retrieves all managed settings
+func (r *ManagedSettingsRepository) GetAll() ([]models.ManagedSetting, error) {
+	var settings []models.ManagedSetting
+	err := r.db.Find(&settings).Error
+	return settings, err
+}
+
+// GetAsMap returns all managed settings as a map
+func (r *ManagedSettingsRepository) GetAsMap() (map[string]string, error) {
+	settings, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for _, setting := range settings {
+		result[setting.Key] = setting.Value
+	}
+
+	return result, nil
+}
+
+// ReplaceAll replaces the cached policy with a freshly fetched one
+func (r *ManagedSettingsRepository) ReplaceAll(settings []models.ManagedSetting) error {
+	fetchedAt := time.Now()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ManagedSetting{}).Error; err != nil {
+			return err
+		}
+
+		for _, setting := range settings {
+			setting.ID = 0
+			setting.FetchedAt = fetchedAt
+			if err := tx.Create(&setting).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
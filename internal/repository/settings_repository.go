@@ -1,11 +1,43 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
 	models "onx-screen-record/internal/common/model"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrNoSettingHistory is returned when there is no version to revert to
+	ErrNoSettingHistory = errors.New("no setting history found")
+
+	// ErrSettingLocked is returned when writing a key enforced by the managed policy
+	ErrSettingLocked = errors.New("setting is managed by policy and cannot be changed")
+
+	// ErrSettingConflict is returned when a setting changed since it was read
+	ErrSettingConflict = errors.New("setting was modified by another writer")
+)
+
+// SettingConflictError reports the version a compare-and-swap write expected
+// and the version currently stored; ActualVersion is 0 when the key is missing
+type SettingConflictError struct {
+	Key             string
+	ExpectedVersion uint
+	ActualVersion   uint
+}
+
+func (e *SettingConflictError) Error() string {
+	return fmt.Sprintf("%s: %s (expected version %d, found %d)", ErrSettingConflict, e.Key, e.ExpectedVersion, e.ActualVersion)
+}
+
+func (e *SettingConflictError) Unwrap() error {
+	return ErrSettingConflict
+}
+
 // SettingsRepository handles app settings database operations
 type SettingsRepository struct {
 	db *gorm.DB
@@ -37,34 +69,71 @@ func (r *SettingsRepository) GetValue(key string) (string, error) {
 
 // Set creates or updates a setting
 func (r *SettingsRepository) Set(key, value, valueType string) error {
-	var setting models.AppSettings
-	result := r.db.Where("key = ?", key).First(&setting)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return r.set(tx, key, value, valueType, "", nil)
+	})
+}
 
-	if result.Error == gorm.ErrRecordNotFound {
-		// Create new setting
-		return r.db.Create(&models.AppSettings{
-			Key:   key,
-			Value: value,
-			Type:  valueType,
-		}).Error
+// SetIfVersion writes a setting only if its stored version still matches
+// expectedVersion; an expectedVersion of 0 means the key must not exist yet.
+// A *SettingConflictError is returned otherwise.
+func (r *SettingsRepository) SetIfVersion(key, value, valueType string, expectedVersion uint) (*models.AppSettings, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return r.set(tx, key, value, valueType, "", &expectedVersion)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return r.Get(key)
+}
 
-	if result.Error != nil {
-		return result.Error
+// SetValueIfVersion updates the value of an existing setting only if its
+// stored version still matches expectedVersion
+func (r *SettingsRepository) SetValueIfVersion(key, value string, expectedVersion uint) (*models.AppSettings, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var setting models.AppSettings
+		result := tx.Where("key = ?", key).First(&setting)
+		if result.Error == gorm.ErrRecordNotFound {
+			return &SettingConflictError{Key: key, ExpectedVersion: expectedVersion}
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return r.set(tx, key, value, setting.Type, "", &expectedVersion)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return r.Get(key)
+}
 
-	// Update existing setting
-	return r.db.Model(&setting).Updates(map[string]interface{}{
-		"value": value,
-		"type":  valueType,
-	}).Error
+// SetMany creates or updates several settings in a single transaction
+func (r *SettingsRepository) SetMany(settings []models.AppSettings) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, setting := range settings {
+			if err := r.set(tx, setting.Key, setting.Value, setting.Type, "", nil); err != nil {
+				return fmt.Errorf("failed to set %s: %w", setting.Key, err)
+			}
+		}
+		return nil
+	})
 }
 
 // SetValue updates only the value of a setting
 func (r *SettingsRepository) SetValue(key, value string) error {
-	return r.db.Model(&models.AppSettings{}).
-		Where("key = ?", key).
-		Update("value", value).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var setting models.AppSettings
+		result := tx.Where("key = ?", key).First(&setting)
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return r.set(tx, key, value, setting.Type, "", nil)
+	})
 }
 
 // GetAll retrieves all settings
@@ -76,7 +145,9 @@ func (r *SettingsRepository) GetAll() ([]models.AppSettings, error) {
 
 // Delete removes a setting by key
 func (r *SettingsRepository) Delete(key string) error {
-	return r.db.Where("key = ?", key).Delete(&models.AppSettings{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return r.delete(tx, key, enum.SETTING_DELETE)
+	})
 }
 
 // GetAsMap returns all settings as a map
@@ -93,3 +164,231 @@ func (r *SettingsRepository) GetAsMap() (map[string]string, error) {
 
 	return result, nil
 }
+
+// IsLocked reports whether a key is enforced by the managed policy
+func (r *SettingsRepository) IsLocked(key string) (bool, error) {
+	return r.isLocked(r.db, key)
+}
+
+// GetHistory returns the recorded versions of a setting, newest first
+func (r *SettingsRepository) GetHistory(key string, limit int) ([]models.SettingHistory, error) {
+	if limit <= 0 || limit > models.MaxSettingHistoryPerKey {
+		limit = models.MaxSettingHistoryPerKey
+	}
+
+	var history []models.SettingHistory
+	err := r.db.Where("key = ?", key).
+		Order("id DESC").
+		Limit(limit).
+		Find(&history).Error
+	return history, err
+}
+
+// RevertTo restores a setting to the version recorded in a history entry
+func (r *SettingsRepository) RevertTo(key string, historyID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var entry models.SettingHistory
+		err := tx.Where("id = ? AND key = ?", historyID, key).First(&entry).Error
+		if err == gorm.ErrRecordNotFound {
+			return ErrNoSettingHistory
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.revert(tx, &entry)
+	})
+}
+
+// RevertToTime restores a setting to the value it had at the given time; a
+// key created after that time is deleted
+func (r *SettingsRepository) RevertToTime(key string, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var entry models.SettingHistory
+		err := tx.Where("key = ? AND created_at <= ?", key, at.UTC()).
+			Order("created_at DESC, id DESC").
+			First(&entry).Error
+		if err == gorm.ErrRecordNotFound {
+			var count int64
+			if err := tx.Model(&models.SettingHistory{}).Where("key = ?", key).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrNoSettingHistory
+			}
+			return r.delete(tx, key, enum.SETTING_REVERT)
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.revert(tx, &entry)
+	})
+}
+
+func (r *SettingsRepository) revert(tx *gorm.DB, entry *models.SettingHistory) error {
+	if entry.Operation == enum.SETTING_DELETE {
+		return r.delete(tx, entry.Key, enum.SETTING_REVERT)
+	}
+	return r.set(tx, entry.Key, entry.Value, entry.Type, enum.SETTING_REVERT, nil)
+}
+
+// set writes a setting and records the new version; an empty operation is
+// resolved to create or update. When expectedVersion is set the write fails
+// with a *SettingConflictError unless the stored version matches it.
+func (r *SettingsRepository) set(tx *gorm.DB, key, value, valueType string, operation enum.SettingOperationEnum, expectedVersion *uint) error {
+	if err := r.checkUnlocked(tx, key); err != nil {
+		return err
+	}
+
+	var setting models.AppSettings
+	result := tx.Where("key = ?", key).First(&setting)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		if expectedVersion != nil && *expectedVersion != 0 {
+			return &SettingConflictError{Key: key, ExpectedVersion: *expectedVersion}
+		}
+		if operation == "" {
+			operation = enum.SETTING_CREATE
+		}
+
+		// Create new setting
+		if err := tx.Create(&models.AppSettings{
+			Key:     key,
+			Value:   value,
+			Type:    valueType,
+			Version: 1,
+		}).Error; err != nil {
+			return err
+		}
+
+		return r.recordHistory(tx, key, value, valueType, operation)
+	}
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if expectedVersion != nil && *expectedVersion != setting.Version {
+		return &SettingConflictError{Key: key, ExpectedVersion: *expectedVersion, ActualVersion: setting.Version}
+	}
+
+	if operation == "" {
+		operation = enum.SETTING_UPDATE
+	}
+
+	if err := r.snapshot(tx, &setting); err != nil {
+		return err
+	}
+
+	// Update existing setting, guarded by the version that was read
+	update := tx.Model(&models.AppSettings{}).
+		Where("id = ? AND version = ?", setting.ID, setting.Version).
+		Updates(map[string]interface{}{
+			"value":   value,
+			"type":    valueType,
+			"version": gorm.Expr("version + 1"),
+		})
+	if update.Error != nil {
+		return update.Error
+	}
+	if update.RowsAffected == 0 {
+		return r.conflict(tx, key, setting.Version)
+	}
+
+	return r.recordHistory(tx, key, value, valueType, operation)
+}
+
+// conflict builds a *SettingConflictError with the currently stored version
+func (r *SettingsRepository) conflict(tx *gorm.DB, key string, expectedVersion uint) error {
+	var current models.AppSettings
+	err := tx.Where("key = ?", key).First(&current).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	return &SettingConflictError{Key: key, ExpectedVersion: expectedVersion, ActualVersion: current.Version}
+}
+
+func (r *SettingsRepository) delete(tx *gorm.DB, key string, operation enum.SettingOperationEnum) error {
+	if err := r.checkUnlocked(tx, key); err != nil {
+		return err
+	}
+
+	var setting models.AppSettings
+	result := tx.Where("key = ?", key).First(&setting)
+	if result.Error == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if err := r.snapshot(tx, &setting); err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&setting).Error; err != nil {
+		return err
+	}
+
+	return r.recordHistory(tx, key, "", setting.Type, operation)
+}
+
+func (r *SettingsRepository) isLocked(tx *gorm.DB, key string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.ManagedSetting{}).Where("key = ?", key).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *SettingsRepository) checkUnlocked(tx *gorm.DB, key string) error {
+	locked, err := r.isLocked(tx, key)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("%w: %s", ErrSettingLocked, key)
+	}
+	return nil
+}
+
+// snapshot records the current value of a setting that has no history yet,
+// so rows written before history existed can still be reverted to
+func (r *SettingsRepository) snapshot(tx *gorm.DB, setting *models.AppSettings) error {
+	var count int64
+	if err := tx.Model(&models.SettingHistory{}).Where("key = ?", setting.Key).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return tx.Create(&models.SettingHistory{
+		Key:       setting.Key,
+		Value:     setting.Value,
+		Type:      setting.Type,
+		Operation: enum.SETTING_SNAPSHOT,
+		CreatedAt: setting.UpdatedAt.UTC(),
+	}).Error
+}
+
+// recordHistory stores a version and prunes versions beyond the per-key
+// bound. Timestamps are stored in UTC so they compare correctly in SQL.
+func (r *SettingsRepository) recordHistory(tx *gorm.DB, key, value, valueType string, operation enum.SettingOperationEnum) error {
+	if err := tx.Create(&models.SettingHistory{
+		Key:       key,
+		Value:     value,
+		Type:      valueType,
+		Operation: operation,
+		CreatedAt: time.Now().UTC(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record setting history: %w", err)
+	}
+
+	return tx.Exec(
+		`DELETE FROM app_settings_history
+		WHERE key = ? AND id NOT IN (
+			SELECT id FROM app_settings_history WHERE key = ? ORDER BY id DESC LIMIT ?
+		)`,
+		key, key, models.MaxSettingHistoryPerKey,
+	).Error
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTaskNotFound is returned when no task has the given id
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrTaskLockLost is returned when a worker reports on a task whose
+	// visibility timeout expired and which may have been taken by another worker
+	ErrTaskLockLost = errors.New("task lock lost")
+
+	// ErrTaskNotRetryable is returned when retrying a task that is running
+	// or has succeeded
+	ErrTaskNotRetryable = errors.New("task cannot be retried")
+)
+
+// dequeueAttempts bounds how often Dequeue retries when another worker
+// claims the same task first
+const dequeueAttempts = 3
+
+// TaskRepository handles background task queue database operations. Every
+// timestamp is stored in UTC so they compare correctly in SQL.
+type TaskRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskRepository creates a new TaskRepository instance
+func NewTaskRepository(db *gorm.DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+// Enqueue stores a new pending task
+func (r *TaskRepository) Enqueue(task *models.Task) error {
+	now := time.Now().UTC()
+
+	task.ID = 0
+	task.Status = enum.TASK_PENDING
+	task.Attempts = 0
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	if task.RunAt.IsZero() {
+		task.RunAt = now
+	}
+	task.RunAt = task.RunAt.UTC()
+
+	return r.db.Create(task).Error
+}
+
+// Dequeue claims the next available task for owner, highest priority first,
+// and hides it from other workers for the visibility timeout. Running tasks
+// whose visibility timeout expired are available again. It returns nil when
+// no task is available.
+func (r *TaskRepository) Dequeue(owner string, visibility time.Duration) (*models.Task, error) {
+	for i := 0; i < dequeueAttempts; i++ {
+		task, err := r.claim(owner, visibility)
+		if !errors.Is(err, ErrTaskLockLost) {
+			return task, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *TaskRepository) claim(owner string, visibility time.Duration) (*models.Task, error) {
+	now := time.Now().UTC()
+	lockedUntil := now.Add(visibility)
+
+	var task models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+			enum.TASK_PENDING, now, enum.TASK_RUNNING, now).
+			Order("priority DESC, run_at, id").
+			First(&task).Error
+		if err != nil {
+			return err
+		}
+
+		// The attempts check makes the claim fail if another worker won
+		result := tx.Model(&models.Task{}).
+			Where("id = ? AND status = ? AND attempts = ?", task.ID, task.Status, task.Attempts).
+			Updates(map[string]interface{}{
+				"status":       enum.TASK_RUNNING,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_by":    owner,
+				"locked_until": lockedUntil,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTaskLockLost
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task.Status = enum.TASK_RUNNING
+	task.Attempts++
+	task.LockedBy = owner
+	task.LockedUntil = &lockedUntil
+	task.UpdatedAt = now
+	return &task, nil
+}
+
+// Extend pushes back the visibility timeout of a task owner is running
+func (r *TaskRepository) Extend(id uint, owner string, visibility time.Duration) error {
+	now := time.Now().UTC()
+	return r.updateLocked(id, owner, map[string]interface{}{
+		"locked_until": now.Add(visibility),
+		"updated_at":   now,
+	})
+}
+
+// Complete marks a task owner is running as succeeded
+func (r *TaskRepository) Complete(id uint, owner string) error {
+	now := time.Now().UTC()
+	return r.updateLocked(id, owner, map[string]interface{}{
+		"status":       enum.TASK_SUCCEEDED,
+		"last_error":   "",
+		"locked_by":    "",
+		"locked_until": nil,
+		"finished_at":  now,
+		"updated_at":   now,
+	})
+}
+
+// Fail puts a task owner is running back in the queue until retryAt
+func (r *TaskRepository) Fail(id uint, owner string, message string, retryAt time.Time) error {
+	return r.updateLocked(id, owner, map[string]interface{}{
+		"status":       enum.TASK_PENDING,
+		"last_error":   message,
+		"run_at":       retryAt.UTC(),
+		"locked_by":    "",
+		"locked_until": nil,
+		"updated_at":   time.Now().UTC(),
+	})
+}
+
+// Release puts a task owner is running back in the queue without counting
+// the attempt, for runs interrupted by a shutdown
+func (r *TaskRepository) Release(id uint, owner string, message string) error {
+	now := time.Now().UTC()
+	return r.updateLocked(id, owner, map[string]interface{}{
+		"status":       enum.TASK_PENDING,
+		"attempts":     gorm.Expr("MAX(attempts - 1, 0)"),
+		"last_error":   message,
+		"run_at":       now,
+		"locked_by":    "",
+		"locked_until": nil,
+		"updated_at":   now,
+	})
+}
+
+// Bury moves a task owner is running to the dead-letter state
+func (r *TaskRepository) Bury(id uint, owner string, message string) error {
+	now := time.Now().UTC()
+	return r.updateLocked(id, owner, map[string]interface{}{
+		"status":       enum.TASK_DEAD,
+		"last_error":   message,
+		"locked_by":    "",
+		"locked_until": nil,
+		"finished_at":  now,
+		"updated_at":   now,
+	})
+}
+
+func (r *TaskRepository) updateLocked(id uint, owner string, values map[string]interface{}) error {
+	result := r.db.Model(&models.Task{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, enum.TASK_RUNNING, owner).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskLockLost
+	}
+	return nil
+}
+
+// Get returns a task by id
+func (r *TaskRepository) Get(id uint) (*models.Task, error) {
+	var task models.Task
+	err := r.db.First(&task, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// List returns tasks filtered by status and type, newest first; empty
+// filters match every task
+func (r *TaskRepository) List(status enum.TaskStatusEnum, taskType string, limit int) ([]models.Task, error) {
+	query := r.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if taskType != "" {
+		query = query.Where("type = ?", taskType)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var tasks []models.Task
+	err := query.Find(&tasks).Error
+	return tasks, err
+}
+
+// CountByStatus returns the number of tasks in every status
+func (r *TaskRepository) CountByStatus() (map[enum.TaskStatusEnum]int64, error) {
+	var rows []struct {
+		Status enum.TaskStatusEnum
+		Count  int64
+	}
+	err := r.db.Model(&models.Task{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[enum.TaskStatusEnum]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// Retry makes a dead or waiting task available immediately with a fresh
+// set of attempts
+func (r *TaskRepository) Retry(id uint) (*models.Task, error) {
+	now := time.Now().UTC()
+	result := r.db.Model(&models.Task{}).
+		Where("id = ? AND status IN ?", id, []enum.TaskStatusEnum{enum.TASK_DEAD, enum.TASK_PENDING}).
+		Updates(map[string]interface{}{
+			"status":      enum.TASK_PENDING,
+			"attempts":    0,
+			"run_at":      now,
+			"finished_at": nil,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrTaskNotRetryable
+	}
+	return r.Get(id)
+}
+
+// PruneFinished deletes succeeded and dead tasks finished before the given
+// time
+func (r *TaskRepository) PruneFinished(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status IN ? AND finished_at < ?", []enum.TaskStatusEnum{enum.TASK_SUCCEEDED, enum.TASK_DEAD}, before.UTC()).
+		Delete(&models.Task{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/pkg/db"
+	"onx-screen-record/internal/pkg/logger"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Setup()
+	os.Exit(m.Run())
+}
+
+// newTestDB opens an in-memory database with every migration applied. It
+// has a single connection, as every connection to :memory: is a separate
+// database.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := database.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.NewMigrator(database).Run(); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return database
+}
+
+func enqueueTask(t *testing.T, repo *TaskRepository, task models.Task) *models.Task {
+	t.Helper()
+
+	if task.Type == "" {
+		task.Type = "test"
+	}
+	if task.MaxAttempts == 0 {
+		task.MaxAttempts = 3
+	}
+	if err := repo.Enqueue(&task); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return &task
+}
+
+func getTask(t *testing.T, repo *TaskRepository, id uint) *models.Task {
+	t.Helper()
+
+	task, err := repo.Get(id)
+	if err != nil {
+		t.Fatalf("Get(%d): %v", id, err)
+	}
+	return task
+}
+
+func TestTaskDequeueOrder(t *testing.T) {
+	repo := NewTaskRepository(newTestDB(t))
+
+	now := time.Now()
+	later := enqueueTask(t, repo, models.Task{RunAt: now.Add(time.Hour)})
+	low := enqueueTask(t, repo, models.Task{RunAt: now.Add(-2 * time.Minute)})
+	high := enqueueTask(t, repo, models.Task{Priority: 10})
+	lowNewer := enqueueTask(t, repo, models.Task{RunAt: now.Add(-time.Minute)})
+
+	// Highest priority first, then the earliest due; future tasks wait
+	for _, want := range []uint{high.ID, low.ID, lowNewer.ID} {
+		task, err := repo.Dequeue("worker", time.Minute)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if task == nil || task.ID != want {
+			t.Fatalf("Dequeue = %+v, want task %d", task, want)
+		}
+		if task.Status != enum.TASK_RUNNING || task.Attempts != 1 || task.LockedBy != "worker" || task.LockedUntil == nil {
+			t.Fatalf("claimed task = %+v, want running with attempt 1 locked by worker", task)
+		}
+	}
+
+	task, err := repo.Dequeue("worker", time.Minute)
+	if err != nil || task != nil {
+		t.Fatalf("Dequeue = %+v, %v, want nothing before task %d is due", task, err, later.ID)
+	}
+}
+
+func TestTaskDequeueRace(t *testing.T) {
+	repo := NewTaskRepository(newTestDB(t))
+	task := enqueueTask(t, repo, models.Task{})
+
+	const workers = 8
+	var wg sync.WaitGroup
+	claims := make(chan string, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			claimed, err := repo.Dequeue(owner, time.Minute)
+			if err != nil {
+				t.Errorf("Dequeue: %v", err)
+				return
+			}
+			if claimed != nil {
+				claims <- owner
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+	close(claims)
+
+	var owners []string
+	for owner := range claims {
+		owners = append(owners, owner)
+	}
+	if len(owners) != 1 {
+		t.Fatalf("task claimed by %v, want exactly one worker", owners)
+	}
+	if stored := getTask(t, repo, task.ID); stored.Attempts != 1 || stored.LockedBy != owners[0] {
+		t.Fatalf("stored task = %+v, want one attempt locked by %s", stored, owners[0])
+	}
+}
+
+func TestTaskVisibilityTimeoutExpiry(t *testing.T) {
+	database := newTestDB(t)
+	repo := NewTaskRepository(database)
+	task := enqueueTask(t, repo, models.Task{})
+
+	if claimed, err := repo.Dequeue("crashed", time.Minute); err != nil || claimed == nil {
+		t.Fatalf("Dequeue = %+v, %v, want the task", claimed, err)
+	}
+	if claimed, err := repo.Dequeue("other", time.Minute); err != nil || claimed != nil {
+		t.Fatalf("Dequeue = %+v, %v, want the task hidden while locked", claimed, err)
+	}
+
+	// The visibility timeout of the first worker runs out
+	expired := time.Now().UTC().Add(-time.Second)
+	if err := database.Model(&models.Task{}).Where("id = ?", task.ID).Update("locked_until", expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := repo.Dequeue("other", time.Minute)
+	if err != nil || claimed == nil {
+		t.Fatalf("Dequeue = %+v, %v, want the expired task", claimed, err)
+	}
+	if claimed.Attempts != 2 || claimed.LockedBy != "other" {
+		t.Fatalf("claimed task = %+v, want attempt 2 locked by other", claimed)
+	}
+
+	// The first worker lost the task and cannot report on it anymore
+	for name, report := range map[string]func() error{
+		"Extend":   func() error { return repo.Extend(task.ID, "crashed", time.Minute) },
+		"Complete": func() error { return repo.Complete(task.ID, "crashed") },
+		"Fail":     func() error { return repo.Fail(task.ID, "crashed", "failed", time.Now()) },
+		"Release":  func() error { return repo.Release(task.ID, "crashed", "interrupted") },
+		"Bury":     func() error { return repo.Bury(task.ID, "crashed", "failed") },
+	} {
+		if err := report(); !errors.Is(err, ErrTaskLockLost) {
+			t.Fatalf("%s by the previous owner = %v, want %v", name, err, ErrTaskLockLost)
+		}
+	}
+
+	if err := repo.Complete(task.ID, "other"); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if stored := getTask(t, repo, task.ID); stored.Status != enum.TASK_SUCCEEDED || stored.FinishedAt == nil || stored.LockedBy != "" {
+		t.Fatalf("stored task = %+v, want succeeded and unlocked", stored)
+	}
+}
+
+func TestTaskExtend(t *testing.T) {
+	repo := NewTaskRepository(newTestDB(t))
+	task := enqueueTask(t, repo, models.Task{})
+
+	claimed, err := repo.Dequeue("worker", time.Second)
+	if err != nil || claimed == nil {
+		t.Fatalf("Dequeue = %+v, %v, want the task", claimed, err)
+	}
+	if err := repo.Extend(task.ID, "worker", time.Hour); err != nil {
+		t.Fatalf("Extend: %v", err)
+	}
+	stored := getTask(t, repo, task.ID)
+	if stored.LockedUntil == nil || !stored.LockedUntil.After(claimed.LockedUntil.Add(time.Minute)) {
+		t.Fatalf("locked until %v after Extend, want about an hour from now", stored.LockedUntil)
+	}
+}
+
+func TestTaskFailReleaseAndBury(t *testing.T) {
+	repo := NewTaskRepository(newTestDB(t))
+	task := enqueueTask(t, repo, models.Task{})
+
+	if _, err := repo.Dequeue("worker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	retryAt := time.Now().Add(time.Hour)
+	if err := repo.Fail(task.ID, "worker", "timeout", retryAt); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	stored := getTask(t, repo, task.ID)
+	if stored.Status != enum.TASK_PENDING || stored.Attempts != 1 || stored.LastError != "timeout" || !stored.RunAt.Equal(retryAt.UTC().Round(0)) {
+		t.Fatalf("failed task = %+v, want pending with attempt 1 until %v", stored, retryAt)
+	}
+	if claimed, _ := repo.Dequeue("worker", time.Minute); claimed != nil {
+		t.Fatalf("Dequeue = %+v, want nothing before the retry is due", claimed)
+	}
+
+	// Released tasks are due right away without counting the attempt
+	if _, err := repo.Retry(task.ID); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if _, err := repo.Dequeue("worker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Release(task.ID, "worker", "shutdown"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	stored = getTask(t, repo, task.ID)
+	if stored.Status != enum.TASK_PENDING || stored.Attempts != 0 || stored.LockedBy != "" {
+		t.Fatalf("released task = %+v, want pending without the attempt", stored)
+	}
+
+	claimed, err := repo.Dequeue("worker", time.Minute)
+	if err != nil || claimed == nil || claimed.Attempts != 1 {
+		t.Fatalf("Dequeue = %+v, %v, want the released task at attempt 1", claimed, err)
+	}
+	if _, err := repo.Retry(task.ID); !errors.Is(err, ErrTaskNotRetryable) {
+		t.Fatalf("Retry of a running task = %v, want %v", err, ErrTaskNotRetryable)
+	}
+
+	if err := repo.Bury(task.ID, "worker", "broken"); err != nil {
+		t.Fatalf("Bury: %v", err)
+	}
+	stored = getTask(t, repo, task.ID)
+	if stored.Status != enum.TASK_DEAD || stored.LastError != "broken" || stored.FinishedAt == nil {
+		t.Fatalf("buried task = %+v, want dead with the error", stored)
+	}
+
+	counts, err := repo.CountByStatus()
+	if err != nil || counts[enum.TASK_DEAD] != 1 {
+		t.Fatalf("CountByStatus = %v, %v, want one dead task", counts, err)
+	}
+
+	retried, err := repo.Retry(task.ID)
+	if err != nil || retried.Status != enum.TASK_PENDING || retried.Attempts != 0 {
+		t.Fatalf("Retry = %+v, %v, want pending with fresh attempts", retried, err)
+	}
+	if _, err := repo.Retry(9999); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("Retry of a missing task = %v, want %v", err, ErrTaskNotFound)
+	}
+}
+
+func TestTaskPruneFinished(t *testing.T) {
+	repo := NewTaskRepository(newTestDB(t))
+	done := enqueueTask(t, repo, models.Task{})
+	pending := enqueueTask(t, repo, models.Task{})
+
+	if _, err := repo.Dequeue("worker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Complete(done.ID, "worker"); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := repo.PruneFinished(time.Now().Add(time.Minute))
+	if err != nil || pruned != 1 {
+		t.Fatalf("PruneFinished = %d, %v, want 1", pruned, err)
+	}
+	if _, err := repo.Get(done.ID); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("Get of a pruned task = %v, want %v", err, ErrTaskNotFound)
+	}
+	getTask(t, repo, pending.ID)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrUploadNotFound is returned when no upload has the given id
+var ErrUploadNotFound = errors.New("upload not found")
+
+// UploadRepository handles resumable upload state database operations
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadRepository creates a new UploadRepository instance
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Create stores a new pending upload
+func (r *UploadRepository) Create(upload *models.Upload) error {
+	upload.ID = 0
+	upload.Status = enum.UPLOAD_PENDING
+	return r.db.Create(upload).Error
+}
+
+// FindResumable returns the latest unfinished upload of a file to an
+// endpoint, or nil when there is none
+func (r *UploadRepository) FindResumable(endpoint, fingerprint string) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.
+		Where("endpoint = ? AND fingerprint = ? AND status <> ?", endpoint, fingerprint, enum.UPLOAD_COMPLETED).
+		Order("id DESC").
+		First(&upload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// SaveProgress stores the upload URL and the offset confirmed by the server;
+// a failed upload becomes pending again
+func (r *UploadRepository) SaveProgress(id uint, uploadURL string, offset int64) error {
+	return r.update(id, map[string]interface{}{
+		"upload_url":    uploadURL,
+		"upload_offset": offset,
+		"status":        enum.UPLOAD_PENDING,
+		"last_error":    "",
+	})
+}
+
+// Complete marks an upload as completed
+func (r *UploadRepository) Complete(id uint) error {
+	now := time.Now().UTC()
+	return r.update(id, map[string]interface{}{
+		"status":       enum.UPLOAD_COMPLETED,
+		"last_error":   "",
+		"completed_at": &now,
+	})
+}
+
+// Fail marks an upload as failed; it is resumed when the file is uploaded
+// again
+func (r *UploadRepository) Fail(id uint, message string) error {
+	return r.update(id, map[string]interface{}{
+		"status":     enum.UPLOAD_FAILED,
+		"last_error": message,
+	})
+}
+
+// Get returns an upload by id
+func (r *UploadRepository) Get(id uint) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.First(&upload, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// List returns the latest uploads, optionally filtered by status
+func (r *UploadRepository) List(status enum.UploadStatusEnum, limit int) ([]models.Upload, error) {
+	query := r.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var uploads []models.Upload
+	err := query.Find(&uploads).Error
+	return uploads, err
+}
+
+// PruneCompleted deletes uploads completed before the given time
+func (r *UploadRepository) PruneCompleted(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status = ? AND completed_at < ?", enum.UPLOAD_COMPLETED, before.UTC()).
+		Delete(&models.Upload{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *UploadRepository) update(id uint, values map[string]interface{}) error {
+	values["updated_at"] = time.Now().UTC()
+	result := r.db.Model(&models.Upload{}).Where("id = ?", id).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadNotFound
+	}
+	return nil
+}
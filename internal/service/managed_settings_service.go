@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	types "onx-screen-record/internal/common/type"
+	"onx-screen-record/internal/pkg/helper"
+	"onx-screen-record/internal/pkg/logger"
+	"onx-screen-record/internal/repository"
+)
+
+// ManagedSettingsPath is the backend endpoint serving the tenant settings policy
+const ManagedSettingsPath = "/api/tenants/%s/settings-policy"
+
+// ManagedSettingEntry is a single enforced setting returned by the backend
+type ManagedSettingEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// ManagedSettingsService fetches the tenant settings policy and caches it
+type ManagedSettingsService struct {
+	managed *repository.ManagedSettingsRepository
+	api     *helper.APIClient
+}
+
+// NewManagedSettingsService creates a new ManagedSettingsService instance
+func NewManagedSettingsService(managed *repository.ManagedSettingsRepository, api *helper.APIClient) *ManagedSettingsService {
+	return &ManagedSettingsService{managed: managed, api: api}
+}
+
+// Refresh fetches the policy for a tenant from the backend at baseURL and
+// replaces the local cache; the cache is kept as is when the backend cannot
+// be reached
+func (s *ManagedSettingsService) Refresh(ctx context.Context, baseURL, tenant string) (int, error) {
+	if baseURL == "" || tenant == "" {
+		return 0, errors.New("baseurl and tenant must be set to fetch managed settings")
+	}
+
+	resp, err := s.api.Request(
+		&helper.HTTPRequestPayload{
+			Method: enum.GET,
+			URL:    strings.TrimRight(baseURL, "/") + fmt.Sprintf(ManagedSettingsPath, url.PathEscape(tenant)),
+		},
+		&helper.HTTPRequestConfig{
+			Ctx: ctx,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch managed settings: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to fetch managed settings: status %d", resp.StatusCode)
+	}
+
+	body, err := helper.JSONToStruct[types.ResponseAPI](resp.Data)
+	if err != nil || body == nil {
+		return 0, fmt.Errorf("invalid managed settings response: %w", err)
+	}
+
+	entries, err := helper.JSONToStruct[[]ManagedSettingEntry](body.Data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid managed settings response: %w", err)
+	}
+
+	settings := make([]models.ManagedSetting, 0)
+	if entries != nil {
+		for _, entry := range *entries {
+			valueType := enum.SettingTypeEnum(entry.Type)
+			if valueType == "" {
+				valueType = enum.SETTING_STRING
+			}
+			if entry.Key == "" {
+				continue
+			}
+			if err := ValidateSettingValue(valueType, entry.Value); err != nil {
+				logger.Warning.Printf("Ignoring managed setting '%s': %v", entry.Key, err)
+				continue
+			}
+
+			settings = append(settings, models.ManagedSetting{
+				Key:   entry.Key,
+				Value: entry.Value,
+				Type:  valueType.ToString(),
+			})
+		}
+	}
+
+	if err := s.managed.ReplaceAll(settings); err != nil {
+		return 0, err
+	}
+
+	return len(settings), nil
+}
+
+// GetAll returns the cached policy
+func (s *ManagedSettingsService) GetAll() ([]models.ManagedSetting, error) {
+	return s.managed.GetAll()
+}
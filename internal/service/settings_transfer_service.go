@@ -0,0 +1,484 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"onx-screen-record/internal/common/enum"
+	models "onx-screen-record/internal/common/model"
+	"onx-screen-record/internal/repository"
+)
+
+// SettingsDocumentVersion is the current version of the export format
+const SettingsDocumentVersion = 1
+
+const (
+	encryptionAlgorithm  = "aes-256-gcm"
+	encryptionKDF        = "pbkdf2-sha256"
+	encryptionIterations = 600000
+	// Imported documents may use other iteration counts within these
+	// bounds; a crafted count could otherwise stall the key derivation
+	minEncryptionIterations = 100000
+	maxEncryptionIterations = 2000000
+	maskedSecretValue       = "********"
+)
+
+// SettingsDocument is the versioned JSON document used for import/export
+type SettingsDocument struct {
+	Version    int                     `json:"version"`
+	App        string                  `json:"app"`
+	ExportedAt time.Time               `json:"exported_at"`
+	Encryption *SettingsEncryption     `json:"encryption,omitempty"`
+	Settings   []SettingsDocumentEntry `json:"settings"`
+}
+
+// SettingsDocumentEntry is a single exported setting
+type SettingsDocumentEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Type      string `json:"type"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+}
+
+// SettingsEncryption describes how secret values in a document are encrypted
+type SettingsEncryption struct {
+	Algorithm  string `json:"algorithm"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+}
+
+// SettingsExportOptions controls how secrets are handled on export
+type SettingsExportOptions struct {
+	IncludeSecrets bool   `json:"includeSecrets"`
+	Passphrase     string `json:"passphrase"`
+}
+
+type SettingChangeAction string
+
+const (
+	SettingChangeAdd       SettingChangeAction = "add"
+	SettingChangeUpdate    SettingChangeAction = "update"
+	SettingChangeUnchanged SettingChangeAction = "unchanged"
+	// SettingChangeLocked entries are enforced by the managed policy and
+	// skipped on import
+	SettingChangeLocked SettingChangeAction = "locked"
+)
+
+// SettingChange describes what importing an entry would do
+type SettingChange struct {
+	Key      string              `json:"key"`
+	Type     string              `json:"type"`
+	Current  string              `json:"current"`
+	Incoming string              `json:"incoming"`
+	Secret   bool                `json:"secret"`
+	Action   SettingChangeAction `json:"action"`
+}
+
+// SettingValidationError describes an entry that cannot be imported
+type SettingValidationError struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// SettingsImportPreview is the diff shown before an import is applied
+type SettingsImportPreview struct {
+	Path    string                   `json:"path,omitempty"`
+	Changes []SettingChange          `json:"changes"`
+	Errors  []SettingValidationError `json:"errors"`
+}
+
+// SettingsTransferService exports and imports settings documents
+type SettingsTransferService struct {
+	appName   string
+	settings  *repository.SettingsRepository
+	validator SettingValidator
+}
+
+// SettingValidator checks the value of a specific setting key, on top of
+// the validation of its declared type
+type SettingValidator func(key, value string) error
+
+// NewSettingsTransferService creates a new SettingsTransferService instance
+func NewSettingsTransferService(appName string, settings *repository.SettingsRepository) *SettingsTransferService {
+	return &SettingsTransferService{
+		appName:  appName,
+		settings: settings,
+	}
+}
+
+// SetValidator sets the key-specific validation applied to imported settings
+func (s *SettingsTransferService) SetValidator(validator SettingValidator) {
+	s.validator = validator
+}
+
+// Export builds a settings document; secrets are skipped unless requested,
+// in which case they are encrypted with the passphrase
+func (s *SettingsTransferService) Export(opts SettingsExportOptions) (*SettingsDocument, error) {
+	if opts.IncludeSecrets && opts.Passphrase == "" {
+		return nil, errors.New("a passphrase is required to export secrets")
+	}
+
+	settings, err := s.settings.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &SettingsDocument{
+		Version:    SettingsDocumentVersion,
+		App:        s.appName,
+		ExportedAt: time.Now().UTC(),
+		Settings:   make([]SettingsDocumentEntry, 0, len(settings)),
+	}
+
+	var key []byte
+	if opts.IncludeSecrets {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		doc.Encryption = &SettingsEncryption{
+			Algorithm:  encryptionAlgorithm,
+			KDF:        encryptionKDF,
+			Iterations: encryptionIterations,
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+		}
+		if key, err = deriveKey(opts.Passphrase, doc.Encryption); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, setting := range settings {
+		entry := SettingsDocumentEntry{
+			Key:   setting.Key,
+			Value: setting.Value,
+			Type:  setting.Type,
+		}
+
+		if IsSecretSetting(setting.Key) {
+			if !opts.IncludeSecrets {
+				continue
+			}
+			if entry.Value, err = encryptValue(key, setting.Value); err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s: %w", setting.Key, err)
+			}
+			entry.Encrypted = true
+		}
+
+		doc.Settings = append(doc.Settings, entry)
+	}
+
+	sort.Slice(doc.Settings, func(i, j int) bool {
+		return doc.Settings[i].Key < doc.Settings[j].Key
+	})
+
+	return doc, nil
+}
+
+// ExportToFile writes a settings document to path
+func (s *SettingsTransferService) ExportToFile(path string, opts SettingsExportOptions) error {
+	doc, err := s.Export(opts)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0600)
+}
+
+// ReadFile reads a settings document from path
+func (s *SettingsTransferService) ReadFile(path string) (*SettingsDocument, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc SettingsDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid settings document: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// Preview compares a document with the stored settings without writing
+func (s *SettingsTransferService) Preview(doc *SettingsDocument, passphrase string) (*SettingsImportPreview, error) {
+	preview, _, err := s.plan(doc, passphrase)
+	return preview, err
+}
+
+// Import validates a document and applies every change in one transaction
+func (s *SettingsTransferService) Import(doc *SettingsDocument, passphrase string) (*SettingsImportPreview, error) {
+	preview, changes, err := s.plan(doc, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preview.Errors) > 0 {
+		return preview, fmt.Errorf("settings document has %d invalid entries", len(preview.Errors))
+	}
+
+	if err := s.settings.SetMany(changes); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// plan decrypts and validates a document, returning the preview and the
+// settings that need to be written
+func (s *SettingsTransferService) plan(doc *SettingsDocument, passphrase string) (*SettingsImportPreview, []models.AppSettings, error) {
+	if doc.Version < 1 || doc.Version > SettingsDocumentVersion {
+		return nil, nil, fmt.Errorf("unsupported settings document version: %d", doc.Version)
+	}
+
+	var key []byte
+	if doc.Encryption != nil {
+		if passphrase == "" {
+			return nil, nil, errors.New("this settings document contains encrypted secrets, a passphrase is required")
+		}
+		var err error
+		if key, err = deriveKey(passphrase, doc.Encryption); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stored, err := s.settings.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := make(map[string]models.AppSettings, len(stored))
+	for _, setting := range stored {
+		current[setting.Key] = setting
+	}
+
+	preview := &SettingsImportPreview{
+		Changes: make([]SettingChange, 0, len(doc.Settings)),
+		Errors:  make([]SettingValidationError, 0),
+	}
+	changes := make([]models.AppSettings, 0, len(doc.Settings))
+	seen := make(map[string]bool, len(doc.Settings))
+
+	for _, entry := range doc.Settings {
+		if entry.Key == "" {
+			preview.Errors = append(preview.Errors, SettingValidationError{Message: "key is required"})
+			continue
+		}
+		if seen[entry.Key] {
+			preview.Errors = append(preview.Errors, SettingValidationError{Key: entry.Key, Message: "duplicate key"})
+			continue
+		}
+		seen[entry.Key] = true
+
+		value := entry.Value
+		if entry.Encrypted {
+			if key == nil {
+				preview.Errors = append(preview.Errors, SettingValidationError{Key: entry.Key, Message: "encrypted value without encryption header"})
+				continue
+			}
+			if value, err = decryptValue(key, entry.Value); err != nil {
+				return nil, nil, errors.New("failed to decrypt secrets, check the passphrase")
+			}
+		}
+
+		locked, err := s.settings.IsLocked(entry.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		existing, exists := current[entry.Key]
+		valueType := enum.SettingTypeEnum(entry.Type)
+		if valueType == "" {
+			valueType = enum.SETTING_STRING
+		}
+
+		if locked {
+			change := SettingChange{
+				Key:      entry.Key,
+				Type:     valueType.ToString(),
+				Current:  existing.Value,
+				Incoming: value,
+				Secret:   IsSecretSetting(entry.Key),
+				Action:   SettingChangeLocked,
+			}
+			if change.Secret {
+				change.Current = maskSecret(change.Current)
+				change.Incoming = maskSecret(change.Incoming)
+			}
+			preview.Changes = append(preview.Changes, change)
+			continue
+		}
+
+		if err := validateSettingEntry(existing, exists, valueType, value); err != nil {
+			preview.Errors = append(preview.Errors, SettingValidationError{Key: entry.Key, Message: err.Error()})
+			continue
+		}
+		if s.validator != nil {
+			if err := s.validator(entry.Key, value); err != nil {
+				preview.Errors = append(preview.Errors, SettingValidationError{Key: entry.Key, Message: err.Error()})
+				continue
+			}
+		}
+
+		change := SettingChange{
+			Key:      entry.Key,
+			Type:     valueType.ToString(),
+			Current:  existing.Value,
+			Incoming: value,
+			Secret:   IsSecretSetting(entry.Key),
+			Action:   SettingChangeAdd,
+		}
+		if exists {
+			change.Action = SettingChangeUpdate
+			if existing.Value == value && existing.Type == change.Type {
+				change.Action = SettingChangeUnchanged
+			}
+		}
+		if change.Action != SettingChangeUnchanged {
+			changes = append(changes, models.AppSettings{Key: entry.Key, Value: value, Type: change.Type})
+		}
+		if change.Secret {
+			change.Current = maskSecret(change.Current)
+			change.Incoming = maskSecret(change.Incoming)
+		}
+
+		preview.Changes = append(preview.Changes, change)
+	}
+
+	return preview, changes, nil
+}
+
+// IsSecretSetting reports whether a setting must not be exported in plain text
+func IsSecretSetting(key string) bool {
+	if slices.Contains(models.SecretSettingKeys, key) {
+		return true
+	}
+	for _, suffix := range []string{"_token", "_password", "_secret"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSettingValue checks that a value can be read as the declared type;
+// empty values mean unset and are valid for every type
+func ValidateSettingValue(valueType enum.SettingTypeEnum, value string) error {
+	if !valueType.IsValid() {
+		return fmt.Errorf("unknown setting type: %s", valueType)
+	}
+	if value == "" {
+		return nil
+	}
+
+	switch valueType {
+	case enum.SETTING_INT:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %q is not an int", value)
+		}
+	case enum.SETTING_BOOL:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value %q is not a bool", value)
+		}
+	case enum.SETTING_JSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("value is not valid json")
+		}
+	}
+
+	return nil
+}
+
+func validateSettingEntry(existing models.AppSettings, exists bool, valueType enum.SettingTypeEnum, value string) error {
+	if exists && existing.Type != "" && existing.Type != valueType.ToString() {
+		return fmt.Errorf("type %s does not match declared type %s", valueType, existing.Type)
+	}
+	return ValidateSettingValue(valueType, value)
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedSecretValue
+}
+
+func deriveKey(passphrase string, encryption *SettingsEncryption) ([]byte, error) {
+	if encryption.Algorithm != encryptionAlgorithm || encryption.KDF != encryptionKDF {
+		return nil, fmt.Errorf("unsupported encryption: %s/%s", encryption.Algorithm, encryption.KDF)
+	}
+
+	if encryption.Iterations < minEncryptionIterations || encryption.Iterations > maxEncryptionIterations {
+		return nil, fmt.Errorf("unsupported key derivation iterations: %d", encryption.Iterations)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(encryption.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %w", err)
+	}
+
+	return pbkdf2.Key(sha256.New, passphrase, salt, encryption.Iterations, 32)
+}
+
+func encryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptValue(key []byte, encoded string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"onx-screen-record/internal/app"
+	"onx-screen-record/internal/pkg/logger"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +14,8 @@ import (
 var assets embed.FS
 
 func main() {
+	logger.Setup()
+
 	app := app.NewApp()
 
 	err := wails.Run(&options.App{
@@ -24,8 +27,12 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.Startup,
+		OnShutdown:       app.Shutdown,
 		Bind: []interface{}{
 			app,
+			app.Jobs,
+			app.Tasks,
+			app.Uploads,
 		},
 	})
 